@@ -1,52 +1,37 @@
 package main
 
 import (
-	"bytes"
 	_ "embed"
-	"io"
-	"log"
-	"os"
+	"strconv"
 
-	"github.com/abilun/keybon/internal/generator/dumb"
-	"github.com/abilun/keybon/internal/ui"
+	"github.com/abilun/keybon/internal/generator/adaptive"
+	"github.com/abilun/keybon/internal/generator/code"
+	"github.com/abilun/keybon/internal/generator/pseudo"
 	"github.com/alecthomas/kong"
 )
 
 var CLI struct {
-	File   string `help:"File to read words from" short:"f" long:"file"`
-	Length int    `help:"Number of words to generate" short:"l" long:"length" default:"10"`
+	Run   RunCmd   `cmd:"" help:"Start a typing session" default:"withargs"`
+	Train TrainCmd `cmd:"" help:"Train an n-gram model and save it"`
+	Merge MergeCmd `cmd:"" help:"Merge n-gram models into one"`
+	Prune PruneCmd `cmd:"" help:"Shrink an n-gram model by dropping rare n-grams and words"`
+	Model ModelCmd `cmd:"" help:"Show the statistics of an n-gram model and evaluate it on held-out text"`
 }
 
 //go:embed assets/english200.txt
 var english200 []byte
 
 func main() {
-	kong.Parse(&CLI)
-	var inputReader io.Reader = bytes.NewReader(english200)
-
-	if CLI.File != "" {
-		file, err := os.Open(CLI.File)
-		if err != nil {
-			log.Fatalf("failed to open file %q: %v", CLI.File, err)
-		}
-		defer file.Close()
-		inputReader = file
-	}
-
-	generator := dumb.New()
-	generator.Fill(inputReader)
-
-	// words := make([]string, 0, CLI.Length)
-	// for i := 0; i < CLI.Length; i++ {
-	// 	word, err := generator.Next()
-	// 	if err != nil {
-	// 		log.Fatalf("generation failed: %v", err)
-	// 	}
-	// 	words = append(words, word)
-	// }
-	// text := strings.Join(words, " ")
-
-	if err := ui.StartMainScreen(generator, CLI.Length); err != nil {
-		log.Fatalf("TUI failed: %v", err)
-	}
+	ctx := kong.Parse(&CLI,
+		kong.Name("keybon"),
+		kong.Description("Terminal typing trainer"),
+		kong.Vars{
+			"codecs":     codecs(),
+			"max_lines":  strconv.Itoa(code.DefaultMaxLines),
+			"min_length": strconv.Itoa(pseudo.DefaultMinLength),
+			"max_length": strconv.Itoa(pseudo.DefaultMaxLength),
+			"candidates": strconv.Itoa(adaptive.DefaultCandidates),
+		},
+	)
+	ctx.FatalIfErrorf(ctx.Run())
 }
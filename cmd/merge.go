@@ -0,0 +1,37 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+)
+
+type MergeCmd struct {
+	Models []string `arg:"" help:"Models to merge, all of the same order, mode and normalization" type:"existingfile"`
+	Output string   `help:"Path to write the merged model to" short:"O" long:"output" required:""`
+
+	SaveFlags `embed:""`
+}
+
+func (c *MergeCmd) Run() error {
+	if err := c.check(); err != nil {
+		return err
+	}
+	if len(c.Models) < 2 {
+		return errors.New("at least two models are needed to merge")
+	}
+
+	merged, err := loadModel(c.Models[0])
+	if err != nil {
+		return err
+	}
+	for _, path := range c.Models[1:] {
+		model, err := loadModel(path)
+		if err != nil {
+			return err
+		}
+		if err := merged.Merge(model); err != nil {
+			return fmt.Errorf("failed to merge %q into %q: %w", path, c.Models[0], err)
+		}
+	}
+	return c.save(merged, c.Output)
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/abilun/keybon/internal/corpus"
+	"github.com/abilun/keybon/internal/generator/ngram"
+)
+
+type ModelCmd struct {
+	Model    string   `arg:"" help:"Saved n-gram model to inspect" type:"existingfile"`
+	Top      int      `help:"Number of most common histories and transitions to list" long:"top" default:"10"`
+	Evaluate []string `help:"Held-out text files to compute the perplexity of the model on, .gz and .zst files are decompressed" short:"e" long:"evaluate" type:"existingfile"`
+}
+
+func (c *ModelCmd) Run() error {
+	codec, err := detectModelCodec(c.Model)
+	if err != nil {
+		return err
+	}
+	model, err := loadModel(c.Model)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	stats := model.Stats(c.Top)
+
+	fmt.Fprintf(w, "codec:\t%s\n", codec)
+	if !model.Created.IsZero() {
+		fmt.Fprintf(w, "created:\t%s\n", model.Created.Format(time.RFC3339))
+		fmt.Fprintf(w, "updated:\t%s\n", model.Updated.Format(time.RFC3339))
+	}
+	fmt.Fprintf(w, "order:\t%d\n", stats.Order)
+	fmt.Fprintf(w, "level:\t%s\n", model.LevelOrWord())
+	fmt.Fprintf(w, "mode:\t%s\n", modelMode(model))
+	fmt.Fprintf(w, "texts trained on:\t%d\n", len(model.Hashes))
+	fmt.Fprintf(w, "vocabulary:\t%d words\n", stats.VocabSize)
+	fmt.Fprintf(w, "histories:\t%d, and %d shorter for back-off\n", stats.Histories, stats.BackoffHistories)
+	fmt.Fprintf(w, "start states:\t%d\n", stats.Starts)
+	fmt.Fprintf(w, "transitions:\t%d, counted %d times\n", stats.Transitions, stats.Total)
+	fmt.Fprintf(w, "dead-end histories:\t%d\n", stats.DeadEnds)
+	fmt.Fprintf(w, "branching:\t%.2f continuations per history on average\n", stats.MeanBranching())
+	w.Flush()
+
+	if len(stats.Branching) > 0 {
+		fmt.Println("\ncontinuations per history:")
+		for i, n := range stats.Branching {
+			low, high := 1<<i, 1<<(i+1)-1
+			bucket := fmt.Sprintf("%d-%d", low, high)
+			if low == high {
+				bucket = fmt.Sprint(low)
+			}
+			fmt.Fprintf(w, "  %s\t%d\t%.1f%%\n", bucket, n, 100*float64(n)/float64(stats.Histories))
+		}
+		w.Flush()
+	}
+
+	if len(stats.TopHistories) > 0 {
+		fmt.Println("\nmost common histories:")
+		for _, h := range stats.TopHistories {
+			fmt.Fprintf(w, "  %q\t%d\t%d continuations\n", h.History, h.Count, h.Continuations)
+		}
+		w.Flush()
+
+		fmt.Println("\nmost common transitions:")
+		for _, t := range stats.TopTransitions {
+			fmt.Fprintf(w, "  %q\t→ %q\t%d\n", t.History, t.Word, t.Count)
+		}
+		w.Flush()
+	}
+
+	for _, path := range c.Evaluate {
+		evaluation, err := evaluateModel(model, path)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("\n%s:\n", path)
+		fmt.Fprintf(w, "  tokens:\t%d, %d unknown\n", evaluation.Tokens, evaluation.Unknown)
+		if evaluation.Tokens > 0 {
+			fmt.Fprintf(w, "  seen:\t%.1f%% after their full history\n",
+				100*float64(evaluation.Seen)/float64(evaluation.Tokens))
+		}
+		fmt.Fprintf(w, "  log-likelihood:\t%.1f\n", evaluation.LogLikelihood)
+		fmt.Fprintf(w, "  cross-entropy:\t%.2f bits per token\n", evaluation.CrossEntropy())
+		fmt.Fprintf(w, "  perplexity:\t%.1f\n", evaluation.Perplexity())
+		w.Flush()
+	}
+	return nil
+}
+
+// modelMode() function describes the mode and normalization of the model.
+func modelMode(model *ngram.Model) string {
+	mode := string(model.Mode)
+	if mode == "" {
+		mode = "letters"
+	}
+	if n := model.Normalization; n != nil {
+		var parts []string
+		if n.Form != "" {
+			parts = append(parts, string(n.Form))
+		}
+		if n.FoldDiacritics {
+			parts = append(parts, "folded diacritics")
+		}
+		if n.FoldCase {
+			parts = append(parts, "folded case")
+		}
+		if n.MinLength > 0 {
+			parts = append(parts, fmt.Sprintf("tokens of %d+ letters", n.MinLength))
+		}
+		if len(n.StopWords) > 0 {
+			parts = append(parts, fmt.Sprintf("%d stop words", len(n.StopWords)))
+		}
+		if len(parts) > 0 {
+			mode += " (" + strings.Join(parts, ", ") + ")"
+		}
+	}
+	return mode
+}
+
+// detectModelCodec() function returns the codec the model at the path
+// was saved with, and for binary files their format version.
+func detectModelCodec(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to open model %q: %w", path, err)
+	}
+	defer file.Close()
+
+	r := bufio.NewReader(file)
+	head, _ := r.Peek(16)
+	codec, err := ngram.DetectCodec(head)
+	if err != nil {
+		return "", fmt.Errorf("failed to load model %q: %w", path, err)
+	}
+	if codec != ngram.CodecBinary {
+		return string(codec), nil
+	}
+
+	header, err := ngram.ReadHeader(r)
+	if err != nil {
+		return "", fmt.Errorf("failed to load model %q: %w", path, err)
+	}
+	return fmt.Sprintf("%s, version %d, %s body", codec, header.Version, header.Compression), nil
+}
+
+// evaluateModel() function scores the model on the text at the path.
+func evaluateModel(model *ngram.Model, path string) (ngram.Evaluation, error) {
+	file, err := corpus.Open(path)
+	if err != nil {
+		return ngram.Evaluation{}, fmt.Errorf("failed to open file %q: %w", path, err)
+	}
+	defer file.Close()
+
+	evaluation, err := model.Evaluate(file)
+	if err != nil {
+		return evaluation, fmt.Errorf("failed to evaluate on %q: %w", path, err)
+	}
+	return evaluation, nil
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/mattn/go-isatty"
+)
+
+// progressBar shows a status line that is rewritten in place on
+// a terminal, with messages printed above it.
+type progressBar struct {
+	w        io.Writer
+	terminal bool
+	quiet    bool
+	shown    bool
+}
+
+// newProgressBar() function creates a progress bar writing to w.
+// The status line is only shown if w is a terminal and not quiet.
+func newProgressBar(w *os.File, quiet bool) *progressBar {
+	return &progressBar{
+		w:        w,
+		terminal: isatty.IsTerminal(w.Fd()) || isatty.IsCygwinTerminal(w.Fd()),
+		quiet:    quiet,
+	}
+}
+
+// Printf() function prints a message on a line of its own.
+func (b *progressBar) Printf(format string, args ...any) {
+	b.clear()
+	fmt.Fprintf(b.w, format+"\n", args...)
+}
+
+// Update() function replaces the status line.
+func (b *progressBar) Update(format string, args ...any) {
+	if b.quiet || !b.terminal {
+		return
+	}
+	b.clear()
+	fmt.Fprintf(b.w, format, args...)
+	b.shown = true
+}
+
+// Done() function ends the status line, keeping it on screen.
+func (b *progressBar) Done() {
+	if b.shown {
+		fmt.Fprintln(b.w)
+		b.shown = false
+	}
+}
+
+func (b *progressBar) clear() {
+	if b.shown {
+		fmt.Fprint(b.w, "\r\x1b[K")
+		b.shown = false
+	}
+}
+
+// formatBytes() function formats a size in bytes for humans.
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+)
+
+type PruneCmd struct {
+	Model    string `arg:"" help:"Model to prune" type:"existingfile"`
+	Output   string `help:"Path to write the pruned model to" short:"O" long:"output" required:""`
+	MinCount int    `help:"Drop continuations and start states seen fewer times than this" long:"min-count"`
+	MaxVocab int    `help:"Keep only this many of the most frequent words" long:"max-vocab"`
+	Unknown  string `help:"Word to replace the words dropped by --max-vocab with, instead of dropping the n-grams containing them" long:"unknown"`
+
+	SaveFlags `embed:""`
+}
+
+func (c *PruneCmd) Run() error {
+	if err := c.check(); err != nil {
+		return err
+	}
+	if c.MinCount < 1 && c.MaxVocab < 1 {
+		return errors.New("--min-count or --max-vocab is required")
+	}
+	if c.Unknown != "" && c.MaxVocab < 1 {
+		return errors.New("--unknown can only be used with --max-vocab")
+	}
+
+	model, err := loadModel(c.Model)
+	if err != nil {
+		return err
+	}
+
+	// The vocabulary is capped first, so words are ranked by
+	// their full counts and merged counts can pass --min-count
+	if c.MaxVocab > 0 {
+		removed, err := model.CapVocab(c.MaxVocab, c.Unknown)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "removed %d rare words\n", removed)
+	}
+	if c.MinCount > 0 {
+		removed := model.Prune(c.MinCount)
+		fmt.Fprintf(os.Stderr, "removed %d continuations seen fewer than %d times\n", removed, c.MinCount)
+	}
+
+	if model.IsEmpty() {
+		return errors.New("no n-grams left after pruning")
+	}
+	return c.save(model, c.Output)
+}
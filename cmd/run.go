@@ -0,0 +1,650 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/abilun/keybon/internal/corpus"
+	"github.com/abilun/keybon/internal/generator"
+	"github.com/abilun/keybon/internal/generator/adaptive"
+	"github.com/abilun/keybon/internal/generator/book"
+	"github.com/abilun/keybon/internal/generator/code"
+	"github.com/abilun/keybon/internal/generator/dumb"
+	"github.com/abilun/keybon/internal/generator/filter"
+	"github.com/abilun/keybon/internal/generator/inject"
+	"github.com/abilun/keybon/internal/generator/lesson"
+	"github.com/abilun/keybon/internal/generator/ngram"
+	"github.com/abilun/keybon/internal/generator/pseudo"
+	"github.com/abilun/keybon/internal/generator/quote"
+	"github.com/abilun/keybon/internal/scanner"
+	"github.com/abilun/keybon/internal/typing"
+	"github.com/abilun/keybon/internal/ui"
+)
+
+const (
+	// textCorpus is the --corpus of plain text files.
+	textCorpus = "text"
+	// sourceCorpus is the --corpus of source code identifiers.
+	sourceCorpus = "source"
+	// documentCorpus is the --corpus of the prose of Markdown,
+	// HTML, EPUB and subtitle files.
+	documentCorpus = "document"
+)
+
+type RunCmd struct {
+	File             string `help:"File to read words from, or the path of the --corpus" short:"f" long:"file"`
+	Corpus           string `help:"Kind of text to read (${enum}), histories and git log are read from their usual place without --file" long:"corpus" enum:"text,document,bash-history,zsh-history,git-log,source" default:"text"`
+	SplitIdentifiers bool   `help:"Split the identifiers of --corpus=source into their camelCase and snake_case parts" long:"split-identifiers"`
+	Length           int    `help:"Number of words to generate" short:"l" long:"length" default:"10"`
+	Top              int    `help:"Only use the N most frequent words of the word list" short:"t" long:"top"`
+	Generator        string `help:"Text generator to use (${enum})" short:"g" long:"generator" enum:"dumb,ngram,pseudo,quote,book,code" default:"dumb"`
+	Order            *int   `help:"Order of the n-gram model trained from --file, at most 8 for --generator=ngram (default: 2, or the order of --model)" short:"o" long:"order"`
+	Model            string `help:"Saved n-gram model to load" short:"m" long:"model" type:"existingfile"`
+	Prose            bool   `help:"Train the n-gram model on sentences, keeping punctuation and case" long:"prose"`
+
+	TokenizeFlags `embed:""`
+
+	ChunkSize int  `help:"Approximate length of book chunks in characters" long:"chunk-size" default:"250"`
+	Restart   bool `help:"Start the book from the beginning instead of its bookmark" long:"restart"`
+
+	MaxLines   int  `help:"Skip code snippets longer than this many lines" long:"max-lines" default:"${max_lines}"`
+	SkipIndent bool `help:"Fill in the indentation after newlines automatically" long:"skip-indent"`
+
+	QuoteLength []string `help:"Lengths of quotes to use (${enum})" long:"quote-length" enum:"short,medium,long,thicc" sep:","`
+
+	MinLength int `help:"Minimum length of pseudo-words" long:"min-length" default:"${min_length}"`
+	MaxLength int `help:"Maximum length of pseudo-words" long:"max-length" default:"${max_length}"`
+
+	Sampling    string  `help:"N-gram sampling strategy (${enum})" long:"sampling" enum:"weighted,uniform,temperature,top-k,top-p,most-likely" default:"weighted"`
+	Temperature float64 `help:"Temperature for --sampling=temperature" long:"temperature" default:"1.0"`
+	TopK        int     `help:"Number of candidates for --sampling=top-k" long:"top-k" default:"5"`
+	TopP        float64 `help:"Cumulative probability for --sampling=top-p" long:"top-p" default:"0.9"`
+
+	Seed *int64 `help:"Seed for reproducible text generation" short:"s" long:"seed"`
+
+	MinWordLength int      `help:"Skip words shorter than this" long:"min-word-length"`
+	MaxWordLength int      `help:"Skip words longer than this" long:"max-word-length"`
+	AllowedChars  string   `help:"Only use words made of these characters" long:"allowed-chars"`
+	RequiredChars string   `help:"Only use words containing at least one of these characters" long:"required-chars"`
+	Ban           []string `help:"Words to never use" long:"ban" sep:","`
+	NoRepeat      int      `help:"Do not repeat a word within the last N words" long:"no-repeat"`
+
+	Adaptive   bool   `help:"Prefer words with the keys and bigrams you are weakest at" short:"a" long:"adaptive"`
+	Candidates int    `help:"Number of words --adaptive chooses each word from, more gives more practice and less variety" long:"candidates" default:"${candidates}"`
+	Stats      string `help:"File to keep typing statistics in, always updated when given (default: in the user config directory with --adaptive or --lesson)" long:"stats"`
+
+	Punctuation bool `help:"Add commas, sentence ends and quotes to the text" short:"p" long:"punctuation"`
+	Numbers     bool `help:"Add numbers to the text" short:"n" long:"numbers"`
+	Capitals    bool `help:"Capitalize sentence starts, or random words without --punctuation" short:"c" long:"capitals"`
+
+	Lesson         bool    `help:"Start with the home row and unlock keys as you master them" long:"lesson"`
+	TargetWPM      float64 `help:"Speed required to unlock the next key in --lesson" long:"target-wpm" default:"35"`
+	TargetAccuracy float64 `help:"Accuracy in percent required to unlock the next key in --lesson" long:"target-accuracy" default:"95"`
+}
+
+func (c *RunCmd) Run() error {
+	inputReader, err := c.input()
+	if err != nil {
+		return err
+	}
+	defer inputReader.Close()
+
+	statsPath, stats, err := c.keyStats()
+	if err != nil {
+		return err
+	}
+
+	gen, err := c.buildGenerator(inputReader, stats)
+	if err != nil {
+		return err
+	}
+
+	var saveErr, completeErr error
+	recordSession := ui.WithSessionHook(func(ts typing.TypingSession) {
+		if stats != nil {
+			stats.AddSession(ts)
+			saveErr = typing.SaveKeyStats(statsPath, stats)
+		}
+		if completer, ok := gen.(generator.Completer); ok && completeErr == nil {
+			completeErr = completer.CompletePassage()
+		}
+	})
+
+	opts := []ui.Option{recordSession}
+	if c.SkipIndent {
+		opts = append(opts, ui.WithSkipIndent())
+	}
+
+	if err := ui.StartMainScreen(gen, c.Length, opts...); err != nil {
+		return fmt.Errorf("TUI failed: %w", err)
+	}
+	if saveErr != nil {
+		return fmt.Errorf("failed to save statistics %q: %w", statsPath, saveErr)
+	}
+	if completeErr != nil {
+		return fmt.Errorf("failed to save bookmark: %w", completeErr)
+	}
+	return nil
+}
+
+// Validate() function rejects an --order the n-gram generator cannot
+// use, before any model is trained. Kong calls it after parsing.
+func (c *RunCmd) Validate() error {
+	if c.Generator == "ngram" && c.order() > ngram.MaxCompactOrder {
+		return fmt.Errorf("--order %d is too high: --generator=ngram supports orders up to %d",
+			c.order(), ngram.MaxCompactOrder)
+	}
+	return nil
+}
+
+// order() function returns the --order of trained models, 2 unless given.
+func (c *RunCmd) order() int {
+	if c.Order == nil {
+		return 2
+	}
+	return *c.Order
+}
+
+// checkOrder() function rejects a --model of another order
+// than the --order, if one is given.
+func (c *RunCmd) checkOrder(order int) error {
+	if c.Order != nil && *c.Order != order {
+		return fmt.Errorf("%w: --order is %d, but %q has order %d",
+			ngram.ErrOrderMismatch, *c.Order, c.Model, order)
+	}
+	return nil
+}
+
+// input() function returns the text to generate from: the embedded
+// word list, the --file, or the entries of the --corpus, one per line.
+func (c *RunCmd) input() (io.ReadCloser, error) {
+	if c.Corpus == sourceCorpus {
+		if c.File == "" {
+			return nil, errors.New("source corpus needs a source file or directory given by --file")
+		}
+		var buf bytes.Buffer
+		err := walkSources(c.File, func(path string, src []byte) error {
+			buf.Write(src)
+			buf.WriteByte('\n')
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		return io.NopCloser(&buf), nil
+	}
+
+	if c.Corpus == documentCorpus {
+		if c.File == "" {
+			return nil, errors.New("document corpus needs a document given by --file")
+		}
+		r, err := corpus.ReadDocument(c.File)
+		if err != nil {
+			return nil, err
+		}
+		return io.NopCloser(r), nil
+	}
+
+	if c.Corpus != textCorpus {
+		kind := corpus.Kind(c.Corpus)
+		path := c.File
+		if path == "" {
+			p, err := corpus.DefaultPath(kind)
+			if err != nil {
+				return nil, err
+			}
+			path = p
+		}
+
+		lines, err := corpus.Lines(kind, path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s %q: %w", kind, path, err)
+		}
+		return io.NopCloser(strings.NewReader(strings.Join(lines, "\n"))), nil
+	}
+
+	if c.File == "" {
+		return io.NopCloser(bytes.NewReader(english200)), nil
+	}
+	file, err := corpus.Open(c.File)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file %q: %w", c.File, err)
+	}
+	return file, nil
+}
+
+// hasInput() function reports whether a text other
+// than the embedded word list was given.
+func (c *RunCmd) hasInput() bool {
+	return c.File != "" || c.Corpus != textCorpus
+}
+
+// inputName() function names the input text.
+func (c *RunCmd) inputName() string {
+	if c.File != "" {
+		return filepath.Base(c.File)
+	}
+	return c.Corpus
+}
+
+// mode() function returns the scanner mode the input is split with,
+// unless --tokenizer is given: source code is split into identifiers,
+// histories and git log keep whole whitespace separated fields,
+// so flags, pipes and paths survive.
+func (c *RunCmd) mode() scanner.Mode {
+	switch {
+	case c.Tokenizer != autoTokenizer:
+		return scanner.Mode(c.Tokenizer)
+	case c.Corpus == sourceCorpus && c.SplitIdentifiers:
+		return scanner.ModeIdentifierParts
+	case c.Corpus == sourceCorpus:
+		return scanner.ModeIdentifiers
+	case c.Corpus != textCorpus && c.Corpus != documentCorpus:
+		return scanner.ModeFields
+	case c.Prose:
+		return scanner.ModeProse
+	default:
+		return scanner.ModeLetters
+	}
+}
+
+// buildGenerator() function creates the generator selected by the flags,
+// decorates it and seeds it, the decorators included.
+func (c *RunCmd) buildGenerator(r io.Reader, stats *typing.KeyStats) (generator.Generator, error) {
+	gen, err := c.newGenerator(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create generator: %w", err)
+	}
+	// Passages are typed verbatim, so only word generators are decorated
+	if _, ok := gen.(generator.Passager); !ok {
+		gen, err = c.decorate(gen, stats)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if s, ok := gen.(generator.Seeder); ok {
+		c.seed(s)
+	}
+	return gen, nil
+}
+
+// decorate() function wraps the word generator in the
+// filters, the adaptive and lesson modes and the injection.
+func (c *RunCmd) decorate(gen generator.Generator, stats *typing.KeyStats) (generator.Generator, error) {
+	gen = c.filter(gen)
+	if c.Adaptive {
+		a := adaptive.New(gen, stats)
+		if err := a.SetCandidates(c.Candidates); err != nil {
+			return nil, err
+		}
+		gen = a
+	}
+	if c.Lesson {
+		l := lesson.New(gen, stats)
+		if err := l.SetTargets(c.TargetWPM, c.TargetAccuracy); err != nil {
+			return nil, err
+		}
+		gen = l
+	}
+	if c.Punctuation || c.Numbers || c.Capitals {
+		gen = inject.New(gen, inject.Options{
+			Punctuation: c.Punctuation,
+			Numbers:     c.Numbers,
+			Capitals:    c.Capitals,
+			// Injected characters bypass the filter, which runs first
+			AllowedChars: c.AllowedChars,
+		})
+	}
+	return gen, nil
+}
+
+// filter() function wraps the generator in the filters given by the flags.
+func (c *RunCmd) filter(gen generator.Generator) generator.Generator {
+	var predicates []filter.Predicate
+	if c.MinWordLength > 0 {
+		predicates = append(predicates, filter.MinLength(c.MinWordLength))
+	}
+	if c.MaxWordLength > 0 {
+		predicates = append(predicates, filter.MaxLength(c.MaxWordLength))
+	}
+	if c.AllowedChars != "" {
+		predicates = append(predicates, filter.AllowedChars(c.AllowedChars))
+	}
+	if c.RequiredChars != "" {
+		predicates = append(predicates, filter.RequiredChars(c.RequiredChars))
+	}
+	if len(c.Ban) > 0 {
+		predicates = append(predicates, filter.Banned(c.Ban))
+	}
+
+	if len(predicates) > 0 {
+		gen = filter.New(gen, filter.All(predicates...))
+	}
+	if c.NoRepeat > 0 {
+		gen = filter.NewNoRepeat(gen, c.NoRepeat)
+	}
+	return gen
+}
+
+// keyStats() function loads the accumulated typing statistics
+// from --stats or the default location. They are only kept
+// for --adaptive and --lesson, or when --stats is given,
+// otherwise it returns nil statistics.
+func (c *RunCmd) keyStats() (string, *typing.KeyStats, error) {
+	if !c.Adaptive && !c.Lesson && c.Stats == "" {
+		return "", nil, nil
+	}
+
+	path := c.Stats
+	if path == "" {
+		p, err := typing.DefaultKeyStatsPath()
+		if err != nil {
+			return "", nil, fmt.Errorf("failed to locate statistics: %w", err)
+		}
+		path = p
+	}
+
+	stats, err := typing.LoadKeyStats(path)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to load statistics %q: %w", path, err)
+	}
+	return path, stats, nil
+}
+
+// newGenerator() function creates the generator selected by the --generator flag.
+func (c *RunCmd) newGenerator(r io.Reader) (generator.Generator, error) {
+	switch c.Generator {
+	case "ngram":
+		return c.newNgramGenerator(r)
+	case "pseudo":
+		return c.newPseudoGenerator(r)
+	case "quote":
+		return c.newQuoteGenerator(r)
+	case "book":
+		return c.newBookGenerator(r)
+	case "code":
+		return c.newCodeGenerator()
+	default:
+		g := dumb.New()
+		g.SetMode(c.mode())
+		normalization, err := c.normalization(c.mode())
+		if err != nil {
+			return nil, err
+		}
+		if err := g.SetNormalization(normalization); err != nil {
+			return nil, err
+		}
+		if err := g.Fill(r); err != nil {
+			return nil, err
+		}
+		if err := g.SetTop(c.Top); err != nil {
+			return nil, err
+		}
+		return g, nil
+	}
+}
+
+// seed() function seeds the generator if --seed is given.
+func (c *RunCmd) seed(g generator.Seeder) {
+	if c.Seed != nil {
+		g.Seed(*c.Seed)
+	}
+}
+
+// newNgramGenerator() function creates a word level n-gram generator.
+// Only the compact form is kept while generating, saved models
+// are loaded straight into it.
+func (c *RunCmd) newNgramGenerator(r io.Reader) (*ngram.Generator, error) {
+	var compact *ngram.Compact
+	if c.Model != "" {
+		var err error
+		if compact, err = c.loadCompact(); err != nil {
+			return nil, err
+		}
+	} else {
+		model, err := c.model(r, ngram.LevelWord, (*ngram.Model).Fill)
+		if err != nil {
+			return nil, err
+		}
+		if compact, err = model.Compact(); err != nil {
+			return nil, err
+		}
+	}
+
+	ng := ngram.NewFromCompact(compact)
+	ng.NextFunc(c.sampling())
+	c.seed(ng)
+	if err := ng.Start(); err != nil {
+		return nil, err
+	}
+	return ng, nil
+}
+
+// newPseudoGenerator() function creates a character level
+// generator of pronounceable pseudo-words.
+func (c *RunCmd) newPseudoGenerator(r io.Reader) (*pseudo.Generator, error) {
+	model, err := c.model(r, ngram.LevelChar, func(m *ngram.Model, r io.Reader) error {
+		return (&pseudo.Model{Model: m}).Fill(r)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	g := pseudo.NewFromModel(&pseudo.Model{Model: model})
+	if err := g.SetLength(c.MinLength, c.MaxLength); err != nil {
+		return nil, err
+	}
+	g.NextFunc(c.sampling())
+	c.seed(g)
+	return g, nil
+}
+
+// newQuoteGenerator() function creates a generator
+// of passages from the quotes corpus given by --file.
+func (c *RunCmd) newQuoteGenerator(r io.Reader) (*quote.Generator, error) {
+	if !c.hasInput() {
+		return nil, errors.New("quote mode needs a quotes corpus given by --file")
+	}
+
+	g := quote.New()
+	if err := g.Fill(r); err != nil {
+		return nil, err
+	}
+
+	lengths := make([]quote.Length, 0, len(c.QuoteLength))
+	for _, l := range c.QuoteLength {
+		lengths = append(lengths, quote.Length(l))
+	}
+	g.SetLengths(lengths...)
+	return g, nil
+}
+
+// newBookGenerator() function creates a generator walking through
+// the book given by --file, resumed from its bookmark.
+func (c *RunCmd) newBookGenerator(r io.Reader) (*book.Generator, error) {
+	if !c.hasInput() {
+		return nil, errors.New("book mode needs a text given by --file")
+	}
+
+	g, err := book.New(c.inputName(), r)
+	if err != nil {
+		return nil, err
+	}
+	if err := g.SetChunkSize(c.ChunkSize); err != nil {
+		return nil, err
+	}
+
+	path, err := book.DefaultBookmarksPath()
+	if err != nil {
+		return nil, fmt.Errorf("failed to locate bookmarks: %w", err)
+	}
+	bookmarks, err := book.LoadBookmarks(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load bookmarks %q: %w", path, err)
+	}
+	g.UseBookmarks(bookmarks)
+
+	if c.Restart {
+		if err := g.Restart(); err != nil {
+			return nil, err
+		}
+	}
+	return g, nil
+}
+
+// newCodeGenerator() function creates a generator of snippets
+// from the source file, or the source files in the directory,
+// given by --file.
+func (c *RunCmd) newCodeGenerator() (*code.Generator, error) {
+	if c.File == "" {
+		return nil, errors.New("code mode needs a source file or directory given by --file")
+	}
+
+	g := code.New()
+	if err := g.SetMaxLines(c.MaxLines); err != nil {
+		return nil, err
+	}
+
+	err := walkSources(c.File, func(path string, src []byte) error {
+		if err := g.AddFile(path, src); err != nil {
+			log.Printf("skipping %q: %v", path, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return g, nil
+}
+
+// walkSources() function calls fn with the contents of the file at root,
+// or of every source file found in the directory at root, skipping
+// hidden directories.
+func walkSources(root string, fn func(path string, src []byte) error) error {
+	return filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() && path != root && strings.HasPrefix(d.Name(), ".") {
+			return filepath.SkipDir
+		}
+		if !d.Type().IsRegular() || (path != root && !code.IsSource(path)) {
+			return nil
+		}
+
+		src, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read file %q: %w", path, err)
+		}
+		return fn(path, src)
+	})
+}
+
+// model() function loads the model given by --model, which must be of
+// the given level, or trains a new one of the given --order and level
+// on the input reader using fill.
+func (c *RunCmd) model(r io.Reader, level ngram.Level, fill func(*ngram.Model, io.Reader) error) (*ngram.Model, error) {
+	if c.Model != "" {
+		model, err := loadModel(c.Model)
+		if err != nil {
+			return nil, err
+		}
+		// Models saved before levels existed are trusted
+		if model.Level != "" && model.Level != level {
+			return nil, fmt.Errorf("%w: %q is a %s level model, not %s",
+				ngram.ErrLevelMismatch, c.Model, model.Level, level)
+		}
+		if err := c.checkOrder(model.Order); err != nil {
+			return nil, err
+		}
+		return model, nil
+	}
+
+	if c.order() < 1 {
+		return nil, fmt.Errorf("invalid order %d: must be greater than 0", c.order())
+	}
+	model := ngram.NewModel(c.order())
+	model.Level = level
+	model.Mode = c.mode()
+	normalization, err := c.normalization(c.mode())
+	if err != nil {
+		return nil, err
+	}
+	if !normalization.IsZero() {
+		model.Normalization = &normalization
+	}
+	if err := fill(model, r); err != nil {
+		return nil, fmt.Errorf("failed to train model: %w", err)
+	}
+	return model, nil
+}
+
+// sampling() function returns the choice function selected by --sampling,
+// nil for the weighted choice the generators make by default.
+func (c *RunCmd) sampling() ngram.ChoiceFunc {
+	switch c.Sampling {
+	case "uniform":
+		return ngram.RandomChoice
+	case "temperature":
+		return ngram.Temperature(c.Temperature)
+	case "top-k":
+		return ngram.TopK(c.TopK)
+	case "top-p":
+		return ngram.TopP(c.TopP)
+	case "most-likely":
+		return ngram.MostLikely
+	default:
+		return nil
+	}
+}
+
+// loadCompact() function reads the --model into its compact form,
+// which must have the --order, if one is given.
+func (c *RunCmd) loadCompact() (*ngram.Compact, error) {
+	file, err := os.Open(c.Model)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open model %q: %w", c.Model, err)
+	}
+	defer file.Close()
+
+	// The order of binary models is checked before their body is decoded
+	if header, err := ngram.ReadHeader(file); err == nil {
+		if err := c.checkOrder(header.Order); err != nil {
+			return nil, err
+		}
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return nil, fmt.Errorf("failed to read model %q: %w", c.Model, err)
+	}
+
+	compact, err := ngram.LoadCompact(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load model %q: %w", c.Model, err)
+	}
+	if err := c.checkOrder(compact.Order); err != nil {
+		return nil, err
+	}
+	return compact, nil
+}
+
+// loadModel() function reads a saved n-gram model from the given path.
+func loadModel(path string) (*ngram.Model, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open model %q: %w", path, err)
+	}
+	defer file.Close()
+
+	var model ngram.Model
+	if err := model.Load(file); err != nil {
+		return nil, fmt.Errorf("failed to load model %q: %w", path, err)
+	}
+	return &model, nil
+}
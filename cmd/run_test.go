@@ -0,0 +1,98 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/abilun/keybon/internal/generator/ngram"
+	"github.com/abilun/keybon/internal/typing"
+)
+
+const testText = `the quick brown fox jumps over the lazy dog.
+the quick red fox runs past the lazy cat.
+a lazy dog sleeps while the quick fox jumps again.`
+
+// saveTestModel() function saves a model of the order
+// trained on testText with the codec and returns its path.
+func saveTestModel(t *testing.T, order int, codec ngram.Codec) string {
+	t.Helper()
+	m := ngram.NewModel(order)
+	if err := m.Fill(strings.NewReader(testText)); err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(t.TempDir(), "model")
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	if err := m.SaveAs(file, codec, 0); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestRunRejectsModelOfAnotherOrder(t *testing.T) {
+	two, three := 2, 3
+	for _, codec := range []ngram.Codec{ngram.CodecBinary, ngram.CodecJSON} {
+		path := saveTestModel(t, 3, codec)
+
+		c := &RunCmd{Generator: "ngram", Model: path, Order: &two}
+		if _, err := c.newNgramGenerator(nil); !errors.Is(err, ngram.ErrOrderMismatch) {
+			t.Errorf("%s: error = %v, want ErrOrderMismatch", codec, err)
+		}
+
+		for _, order := range []*int{nil, &three} {
+			c := &RunCmd{Generator: "ngram", Model: path, Order: order}
+			if _, err := c.newNgramGenerator(nil); err != nil {
+				t.Errorf("%s: %v", codec, err)
+			}
+		}
+	}
+}
+
+func TestSeedReproducesWords(t *testing.T) {
+	words := func(c RunCmd, seed int64) []string {
+		t.Helper()
+		c.Seed = &seed
+		gen, err := c.buildGenerator(strings.NewReader(testText), typing.NewKeyStats())
+		if err != nil {
+			t.Fatal(err)
+		}
+		var words []string
+		for i := 0; i < 50; i++ {
+			word, err := gen.Next()
+			if err != nil {
+				t.Fatal(err)
+			}
+			words = append(words, word)
+		}
+		return words
+	}
+
+	for _, kind := range []string{"dumb", "ngram"} {
+		tests := []struct {
+			name string
+			cmd  RunCmd
+		}{
+			{"plain", RunCmd{}},
+			{"decorated", RunCmd{NoRepeat: 2, Adaptive: true, Candidates: 4,
+				Punctuation: true, Numbers: true, Capitals: true}},
+		}
+		for _, tt := range tests {
+			tt.cmd.Generator = kind
+			first := words(tt.cmd, 1)
+			if again := words(tt.cmd, 1); !reflect.DeepEqual(first, again) {
+				t.Errorf("%s %s: seed 1 gave %q, then %q", kind, tt.name, first, again)
+			}
+			if other := words(tt.cmd, 2); reflect.DeepEqual(first, other) {
+				t.Errorf("%s %s: seeds 1 and 2 both gave %q", kind, tt.name, first)
+			}
+		}
+	}
+}
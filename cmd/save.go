@@ -0,0 +1,61 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/abilun/keybon/internal/generator/ngram"
+)
+
+// SaveFlags configure how a model is saved.
+type SaveFlags struct {
+	Codec            string `help:"Encoding of the saved model: versioned binary, or JSON compressed with zstd, gzip or not at all (${enum})" long:"codec" enum:"${codecs}" default:"binary"`
+	CompressionLevel int    `help:"Compression level of the saved model, 1-22 for binary and zstd, 1-9 for gzip (default: the codec's default)" long:"compression-level"`
+}
+
+// codecs() function returns the values of --codec, as a kong enum.
+func codecs() string {
+	names := make([]string, len(ngram.Codecs()))
+	for i, codec := range ngram.Codecs() {
+		names[i] = string(codec)
+	}
+	return strings.Join(names, ",")
+}
+
+// check() function validates the flags, so they fail before any work is done.
+func (f *SaveFlags) check() error {
+	return ngram.Codec(f.Codec).CheckLevel(f.CompressionLevel)
+}
+
+// save() function writes the model to the path with the codec of the flags.
+// The model is written to a temporary file renamed over the path once
+// complete, so a failed save leaves an existing model intact.
+func (f *SaveFlags) save(model *ngram.Model, path string) (err error) {
+	file, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+"-*")
+	if err != nil {
+		return fmt.Errorf("failed to create %q: %w", path, err)
+	}
+	defer func() {
+		if err != nil {
+			file.Close()
+			os.Remove(file.Name())
+		}
+	}()
+
+	// CreateTemp() makes the file private, models are not
+	if err := file.Chmod(0o644); err != nil {
+		return fmt.Errorf("failed to create %q: %w", path, err)
+	}
+	if err := model.SaveAs(file, ngram.Codec(f.Codec), f.CompressionLevel); err != nil {
+		return fmt.Errorf("failed to save model %q: %w", path, err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to save model %q: %w", path, err)
+	}
+	if err := os.Rename(file.Name(), path); err != nil {
+		return fmt.Errorf("failed to save model %q: %w", path, err)
+	}
+	return nil
+}
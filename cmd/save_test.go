@@ -0,0 +1,51 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/abilun/keybon/internal/generator/ngram"
+)
+
+func TestSaveReplacesModelOnlyOnSuccess(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "model.bin")
+	if err := os.WriteFile(path, []byte("previous model"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	model := ngram.NewModel(2)
+	if err := model.Fill(strings.NewReader(testText)); err != nil {
+		t.Fatal(err)
+	}
+	entries := func() int {
+		t.Helper()
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return len(entries)
+	}
+
+	failing := &SaveFlags{Codec: string(ngram.CodecBinary), CompressionLevel: 99}
+	if err := failing.save(model, path); err == nil {
+		t.Fatal("no error for an invalid compression level")
+	}
+	if data, _ := os.ReadFile(path); string(data) != "previous model" {
+		t.Errorf("failed save left %q", data)
+	}
+	if n := entries(); n != 1 {
+		t.Errorf("failed save left %d files, want 1", n)
+	}
+
+	if err := (&SaveFlags{Codec: string(ngram.CodecBinary)}).save(model, path); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadModel(path); err != nil {
+		t.Errorf("saved model does not load: %v", err)
+	}
+	if n := entries(); n != 1 {
+		t.Errorf("save left %d files, want 1", n)
+	}
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/abilun/keybon/internal/scanner"
+)
+
+// autoTokenizer is the --tokenizer picked from the other flags.
+const autoTokenizer = "auto"
+
+// TokenizeFlags configure how a corpus is split into tokens
+// and how the tokens are normalized.
+type TokenizeFlags struct {
+	Tokenizer      string `help:"Tokenizer splitting the corpus (${enum}), auto picks one from the other flags" long:"tokenizer" enum:"auto,letters,words,punctuation,fields,characters" default:"auto"`
+	Normalize      string `help:"Unicode normalization form of the tokens (${enum})" long:"normalize" enum:"none,nfc,nfkc" default:"none"`
+	FoldDiacritics bool   `help:"Remove accents from the tokens, so café becomes cafe" long:"fold-diacritics"`
+	FoldCase       bool   `help:"Fold the case of the tokens" long:"fold-case"`
+	MinTokenLength int    `help:"Drop tokens shorter than this" long:"min-token-length"`
+	StopWords      string `help:"File of words to drop, one per line" long:"stop-words" type:"existingfile"`
+}
+
+// normalization() function returns the normalization given by the flags.
+// Stop words are normalized like the tokens of the mode, lowercased
+// too if the mode lowercases, so they match them.
+func (f *TokenizeFlags) normalization(mode scanner.Mode) (scanner.Normalization, error) {
+	n := scanner.Normalization{
+		FoldDiacritics: f.FoldDiacritics,
+		FoldCase:       f.FoldCase,
+		MinLength:      f.MinTokenLength,
+	}
+	if f.Normalize != "none" {
+		n.Form = scanner.Form(f.Normalize)
+	}
+	if f.StopWords == "" {
+		return n, nil
+	}
+
+	words, err := readStopWords(f.StopWords)
+	if err != nil {
+		return n, err
+	}
+	pipeline, err := n.Pipeline()
+	if err != nil {
+		return n, err
+	}
+	if scanner.DefaultConfig(mode).Lowercase {
+		pipeline = append(scanner.Pipeline{scanner.Lowercase}, pipeline...)
+	}
+	for _, word := range words {
+		// Stop words shorter than the minimum length are dropped anyway
+		if normalized, ok := pipeline.Apply([]byte(word)); ok {
+			n.StopWords = append(n.StopWords, string(normalized))
+		}
+	}
+	return n, nil
+}
+
+// readStopWords() function reads the words of a stop word file,
+// skipping blank lines and lines starting with #.
+func readStopWords(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open stop words %q: %w", path, err)
+	}
+	defer file.Close()
+
+	var words []string
+	lines := bufio.NewScanner(file)
+	for lines.Scan() {
+		word := strings.TrimSpace(lines.Text())
+		if word != "" && !strings.HasPrefix(word, "#") {
+			words = append(words, word)
+		}
+	}
+	if err := lines.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read stop words %q: %w", path, err)
+	}
+	return words, nil
+}
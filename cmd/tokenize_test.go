@@ -0,0 +1,38 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/abilun/keybon/internal/scanner"
+)
+
+func TestStopWordsNormalizedLikeTokens(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "stop")
+	if err := os.WriteFile(path, []byte("# articles\nThe\n\nCafé\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		mode  scanner.Mode
+		flags TokenizeFlags
+		want  []string
+	}{
+		// Letters are lowercased, so the stop words must be too
+		{scanner.ModeLetters, TokenizeFlags{StopWords: path}, []string{"the", "café"}},
+		{scanner.ModeWords, TokenizeFlags{StopWords: path}, []string{"The", "Café"}},
+		{scanner.ModeWords, TokenizeFlags{StopWords: path, FoldDiacritics: true}, []string{"The", "Cafe"}},
+	}
+	for _, tt := range tests {
+		tt.flags.Normalize = "none"
+		n, err := tt.flags.normalization(tt.mode)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(n.StopWords, tt.want) {
+			t.Errorf("%s: stop words = %q, want %q", tt.mode, n.StopWords, tt.want)
+		}
+	}
+}
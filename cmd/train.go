@@ -0,0 +1,186 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+
+	"github.com/abilun/keybon/internal/corpus"
+	"github.com/abilun/keybon/internal/generator/code"
+	"github.com/abilun/keybon/internal/generator/ngram"
+	"github.com/abilun/keybon/internal/generator/pseudo"
+	"github.com/abilun/keybon/internal/scanner"
+)
+
+type TrainCmd struct {
+	Paths  []string `arg:"" help:"Corpus files, directories or glob patterns to train on, .gz and .zst files are decompressed"`
+	Output string   `help:"Path to write the trained model to" short:"O" long:"output" required:""`
+	Base   string   `help:"Existing model to extend" short:"b" long:"base" type:"existingfile"`
+	Order  *int     `help:"Order of the model, at most 8 for --level=word (default: 2, or the order of --base)" short:"o" long:"order"`
+	Level  string   `help:"Train on sequences of words or of letters for pseudo-words (${enum})" long:"level" enum:"word,char" default:"word"`
+	Prose  bool     `help:"Train on sentences, keeping punctuation and case" long:"prose"`
+
+	Documents        bool `help:"Train on the prose of Markdown, HTML, EPUB and subtitle files, detected by extension or content; files in directories are only read with a document extension" long:"documents"`
+	Source           bool `help:"Train on the identifiers of source code, skipping other files in directories" long:"source"`
+	SplitIdentifiers bool `help:"Split identifiers of --source into their camelCase and snake_case parts" long:"split-identifiers"`
+
+	TokenizeFlags `embed:""`
+	SaveFlags     `embed:""`
+
+	Workers int  `help:"Number of files to read in parallel (default: number of CPUs)" short:"j" long:"workers"`
+	Quiet   bool `help:"Do not show progress" short:"q" long:"quiet"`
+}
+
+func (c *TrainCmd) Run() error {
+	if err := c.check(); err != nil {
+		return err
+	}
+
+	model, err := c.model()
+	if err != nil {
+		return err
+	}
+
+	trainer := ngram.Trainer{
+		Workers:  c.Workers,
+		Open:     c.open,
+		Progress: c.progress(),
+	}
+	if c.Level == "char" {
+		if c.Prose || c.Source || c.Tokenizer != autoTokenizer {
+			return errors.New("--prose, --source and --tokenizer cannot be used with --level=char")
+		}
+		trainer.Fill = func(m *ngram.Model, r io.Reader) error {
+			return (&pseudo.Model{Model: m}).Fill(r)
+		}
+	}
+
+	var match func(path string) bool
+	switch {
+	case c.Source:
+		match = code.IsSource
+	case c.Documents:
+		match = corpus.IsDocument
+	}
+	paths, err := corpus.Expand(c.Paths, match)
+	if err != nil {
+		return err
+	}
+	if err := trainer.Train(model, paths); err != nil {
+		return err
+	}
+
+	if model.IsEmpty() {
+		return errors.New("no n-grams found in the given corpus")
+	}
+
+	return c.save(model, c.Output)
+}
+
+// model() function returns the model to train:
+// either the loaded --base model or a new one of the given --order.
+func (c *TrainCmd) model() (*ngram.Model, error) {
+	if c.Source && (c.Prose || c.Documents) {
+		return nil, errors.New("--prose and --documents cannot be used with --source")
+	}
+	normalization, err := c.normalization(c.mode())
+	if err != nil {
+		return nil, err
+	}
+
+	if c.Base == "" {
+		order := 2
+		if c.Order != nil {
+			order = *c.Order
+		}
+		if order < 1 {
+			return nil, fmt.Errorf("invalid order %d: must be greater than 0", order)
+		}
+		if c.Level == "word" && order > ngram.MaxCompactOrder {
+			return nil, fmt.Errorf("invalid order %d: word models support orders up to %d",
+				order, ngram.MaxCompactOrder)
+		}
+		model := ngram.NewModel(order)
+		model.Level = ngram.Level(c.Level)
+		model.Mode = c.mode()
+		if !normalization.IsZero() {
+			model.Normalization = &normalization
+		}
+		return model, nil
+	}
+
+	model, err := loadModel(c.Base)
+	if err != nil {
+		return nil, err
+	}
+	switch model.Level {
+	case "":
+		// Saved before levels existed, assumed to be of the --level
+		model.Level = ngram.Level(c.Level)
+	case ngram.Level(c.Level):
+	default:
+		return nil, fmt.Errorf("%w: %q is a %s level model, but --level is %s",
+			ngram.ErrLevelMismatch, c.Base, model.Level, c.Level)
+	}
+	if c.Order != nil && *c.Order != model.Order {
+		return nil, fmt.Errorf("%w: --order is %d, but %q has order %d",
+			ngram.ErrOrderMismatch, *c.Order, c.Base, model.Order)
+	}
+	if (c.Prose || c.Source || c.Tokenizer != autoTokenizer) && model.Mode != c.mode() {
+		return nil, fmt.Errorf("%q was trained in %s mode, not %s", c.Base, model.Mode, c.mode())
+	}
+	if !normalization.IsZero() && (model.Normalization == nil ||
+		!reflect.DeepEqual(*model.Normalization, normalization)) {
+		return nil, fmt.Errorf("%q was trained with a different normalization", c.Base)
+	}
+	return model, nil
+}
+
+// mode() function returns the scanner mode the corpus
+// is split with, unless --tokenizer is given.
+func (c *TrainCmd) mode() scanner.Mode {
+	switch {
+	case c.Tokenizer != autoTokenizer:
+		return scanner.Mode(c.Tokenizer)
+	case c.Source && c.SplitIdentifiers:
+		return scanner.ModeIdentifierParts
+	case c.Source:
+		return scanner.ModeIdentifiers
+	case c.Prose:
+		return scanner.ModeProse
+	default:
+		return scanner.ModeLetters
+	}
+}
+
+// open() function opens a corpus file, decompressing it
+// and, with --documents, extracting its prose.
+func (c *TrainCmd) open(path string) (io.ReadCloser, error) {
+	if c.Documents {
+		r, err := corpus.ReadDocument(path)
+		if err != nil {
+			return nil, err
+		}
+		return io.NopCloser(r), nil
+	}
+	return corpus.Open(path)
+}
+
+// progress() function returns the progress callback of the trainer:
+// it warns about skipped files and, on a terminal, shows the files
+// and bytes read so far.
+func (c *TrainCmd) progress() func(ngram.Progress) {
+	bar := newProgressBar(os.Stderr, c.Quiet)
+	return func(p ngram.Progress) {
+		if p.Err != nil {
+			bar.Printf("skipping %q: %v", p.Path, p.Err)
+		}
+		bar.Update("trained on %d/%d files, %s/%s",
+			p.Files, p.TotalFiles, formatBytes(p.Bytes), formatBytes(p.TotalBytes))
+		if p.Files == p.TotalFiles {
+			bar.Done()
+		}
+	}
+}
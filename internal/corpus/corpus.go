@@ -0,0 +1,54 @@
+package corpus
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Kind is a source of practice text other than a plain text file.
+type Kind string
+
+const (
+	BashHistory Kind = "bash-history"
+	ZshHistory  Kind = "zsh-history"
+	GitLog      Kind = "git-log"
+)
+
+// DefaultPath() function returns the usual location of the corpus:
+// the history file in the home directory, or the current repository.
+func DefaultPath(kind Kind) (string, error) {
+	switch kind {
+	case GitLog:
+		return ".", nil
+	case BashHistory, ZshHistory:
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		if kind == BashHistory {
+			return filepath.Join(home, ".bash_history"), nil
+		}
+		return filepath.Join(home, ".zsh_history"), nil
+	default:
+		return "", fmt.Errorf("unknown corpus %q", kind)
+	}
+}
+
+// Lines() function reads the corpus at the path
+// and returns its entries, one command or message line each.
+func Lines(kind Kind, path string) ([]string, error) {
+	switch kind {
+	case GitLog:
+		return ReadGitLog(path)
+	case BashHistory, ZshHistory:
+		file, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+		return ReadShellHistory(file)
+	default:
+		return nil, fmt.Errorf("unknown corpus %q", kind)
+	}
+}
@@ -0,0 +1,106 @@
+package corpus
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Format is the markup of a document.
+type Format string
+
+const (
+	Text      Format = "text"
+	Markdown  Format = "markdown"
+	HTML      Format = "html"
+	EPUB      Format = "epub"
+	Subtitles Format = "subtitles"
+)
+
+// extensions maps file extensions to the formats they are read as.
+var extensions = map[string]Format{
+	".txt":      Text,
+	".md":       Markdown,
+	".markdown": Markdown,
+	".html":     HTML,
+	".htm":      HTML,
+	".xhtml":    HTML,
+	".epub":     EPUB,
+	".srt":      Subtitles,
+	".vtt":      Subtitles,
+}
+
+// srtStart matches the cue number and timing starting a SubRip file.
+var srtStart = regexp.MustCompile(`^\d+\r?\n\d\d:\d\d:\d\d[,.]\d+ -->`)
+
+// DetectFormat() function returns the format of a document
+// from the extension of its path, or else from its content.
+func DetectFormat(path string, data []byte) Format {
+	if format, ok := extensions[strings.ToLower(filepath.Ext(path))]; ok {
+		return format
+	}
+
+	head := bytes.TrimLeft(data[:min(len(data), 512)], "\ufeff \t\r\n")
+	lower := bytes.ToLower(head)
+	switch {
+	case bytes.HasPrefix(data, []byte("PK\x03\x04")) && bytes.Contains(head, []byte("application/epub+zip")):
+		return EPUB
+	case bytes.HasPrefix(head, []byte("WEBVTT")), srtStart.Match(head):
+		return Subtitles
+	case bytes.HasPrefix(lower, []byte("<!doctype html")), bytes.HasPrefix(lower, []byte("<html")):
+		return HTML
+	default:
+		return Text
+	}
+}
+
+// ReadDocument() function reads the document at the path and
+// returns its prose with the markup stripped, paragraphs separated
+// by blank lines. The format is detected by DetectFormat,
+// compressed documents are decompressed first.
+func ReadDocument(path string) (io.Reader, error) {
+	file, err := Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %q: %w", path, err)
+	}
+
+	text, err := ExtractText(DetectFormat(TrimCompression(path), data), data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %q: %w", path, err)
+	}
+	return strings.NewReader(text), nil
+}
+
+// ExtractText() function returns the prose of a document in the given format.
+func ExtractText(format Format, data []byte) (string, error) {
+	switch format {
+	case Text:
+		return string(data), nil
+	case Markdown:
+		return ExtractMarkdown(data), nil
+	case HTML:
+		return ExtractHTML(data)
+	case EPUB:
+		return ExtractEPUB(data)
+	case Subtitles:
+		return ExtractSubtitles(data), nil
+	default:
+		return "", fmt.Errorf("unknown document format %q", format)
+	}
+}
+
+// IsDocument() function reports whether the path has the
+// extension of a document format, possibly compressed.
+func IsDocument(path string) bool {
+	_, ok := extensions[strings.ToLower(filepath.Ext(TrimCompression(path)))]
+	return ok
+}
@@ -0,0 +1,127 @@
+package corpus
+
+import (
+	"testing"
+)
+
+func TestExtractMarkdown(t *testing.T) {
+	const md = "# Title #\n" +
+		"\n" +
+		"Some *emphasis* and __strong__ text with a [link](http://x.y) and `code`.\n" +
+		"It continues here. ![image](i.png)\n" +
+		"\n" +
+		"```go\n" +
+		"func main() {}\n" +
+		"```\n" +
+		"\n" +
+		"- first item\n" +
+		"- second <b>item</b>\n" +
+		"\n" +
+		"> quoted\n" +
+		"\n" +
+		"| a | b |\n" +
+		"|---|---|\n" +
+		"| c | d |\n" +
+		"\n" +
+		"    indented code\n" +
+		"\n" +
+		"---\n" +
+		"[ref]: http://example.com\n"
+	want := "Title\n\n" +
+		"Some emphasis and strong text with a link and code. It continues here.\n\n" +
+		"first item\n\n" +
+		"second item\n\n" +
+		"quoted\n\n" +
+		"a b\n\n" +
+		"c d"
+	if got := ExtractMarkdown([]byte(md)); got != want {
+		t.Errorf("got\n%q\nwant\n%q", got, want)
+	}
+}
+
+func TestExtractHTML(t *testing.T) {
+	const html = `<!DOCTYPE html>
+<html><head><title>Skipped</title><style>p { color: red }</style></head>
+<body>
+<nav><a href="/">Home</a></nav>
+<h1>A &amp; B</h1>
+<p>First <em>paragraph</em>,<br>
+split over lines.
+<p>Second&nbsp;one</p>
+<script>if (a < b) { alert("x") }</script>
+<pre>code</pre>
+<ul><li>item</li></ul>
+</body></html>`
+	want := "A & B\n\n" +
+		"First paragraph, split over lines.\n\n" +
+		"Second one\n\n" +
+		"item"
+	got, err := ExtractHTML([]byte(html))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != want {
+		t.Errorf("got\n%q\nwant\n%q", got, want)
+	}
+}
+
+func TestExtractSubtitles(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{"srt", "1\r\n00:00:01,000 --> 00:00:02,000\r\n<i>Hello</i> there,\r\n\r\n" +
+			"2\r\n00:00:03,000 --> 00:00:04,000\r\n{\\an8}[door slams]\r\n- General Kenobi.\r\n",
+			"Hello there,\nGeneral Kenobi."},
+		{"vtt", "WEBVTT\n\nNOTE a comment\n\n" +
+			"00:01.000 --> 00:02.000 align:start\n<v Bob>Hi ♪ there</v>\n\n" +
+			"intro\n00:03.000 --> 00:04.000\n(LAUGHING) Bye.\n",
+			"Hi there\nBye."},
+	}
+	for _, tt := range tests {
+		if got := ExtractSubtitles([]byte(tt.text)); got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestDetectFormat(t *testing.T) {
+	tests := []struct {
+		path string
+		data string
+		want Format
+	}{
+		{"notes.MD", "", Markdown},
+		{"page.xhtml", "", HTML},
+		{"movie.srt", "", Subtitles},
+		{"page", "\ufeff  <!DOCTYPE html><html>", HTML},
+		{"movie", "1\n00:00:01,000 --> 00:00:02,000\nHi", Subtitles},
+		{"movie", "WEBVTT\n\n", Subtitles},
+		{"readme", "Plain text", Text},
+	}
+	for _, tt := range tests {
+		if got := DetectFormat(tt.path, []byte(tt.data)); got != tt.want {
+			t.Errorf("DetectFormat(%q, %q) = %s, want %s", tt.path, tt.data, got, tt.want)
+		}
+	}
+}
+
+func TestIsDocument(t *testing.T) {
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"book.epub", true},
+		{"notes.md.gz", true},
+		{"page.HTML", true},
+		{"program", false},
+		{"archive.gz", false},
+		{"image.png", false},
+	}
+	for _, tt := range tests {
+		if got := IsDocument(tt.path); got != tt.want {
+			t.Errorf("IsDocument(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+}
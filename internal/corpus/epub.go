@@ -0,0 +1,145 @@
+package corpus
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strings"
+)
+
+// epubContainer is META-INF/container.xml, pointing to the package document.
+type epubContainer struct {
+	Rootfiles []struct {
+		FullPath string `xml:"full-path,attr"`
+	} `xml:"rootfiles>rootfile"`
+}
+
+// epubPackage is the package document listing the files of the book
+// and, in the spine, the order they are read in.
+type epubPackage struct {
+	Manifest []struct {
+		ID        string `xml:"id,attr"`
+		Href      string `xml:"href,attr"`
+		MediaType string `xml:"media-type,attr"`
+	} `xml:"manifest>item"`
+	Spine []struct {
+		IDRef  string `xml:"idref,attr"`
+		Linear string `xml:"linear,attr"`
+	} `xml:"spine>itemref"`
+}
+
+// ExtractEPUB() function returns the text of the chapters of an EPUB
+// book in reading order. Books without a readable package document
+// fall back to their XHTML files in name order.
+func ExtractEPUB(data []byte) (string, error) {
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return "", fmt.Errorf("not an EPUB book: %w", err)
+	}
+
+	files := make(map[string]*zip.File, len(archive.File))
+	for _, f := range archive.File {
+		files[f.Name] = f
+	}
+
+	chapters, err := epubSpine(files)
+	if err != nil || len(chapters) == 0 {
+		chapters = nil
+		for name := range files {
+			if ext := strings.ToLower(path.Ext(name)); ext == ".xhtml" || ext == ".html" || ext == ".htm" {
+				chapters = append(chapters, name)
+			}
+		}
+		sort.Strings(chapters)
+	}
+
+	var texts []string
+	for _, name := range chapters {
+		f, ok := files[name]
+		if !ok {
+			continue
+		}
+		content, err := readZipFile(f)
+		if err != nil {
+			return "", err
+		}
+		text, err := ExtractHTML(content)
+		if err != nil {
+			return "", fmt.Errorf("failed to read chapter %q: %w", name, err)
+		}
+		if text != "" {
+			texts = append(texts, text)
+		}
+	}
+	if len(texts) == 0 {
+		return "", errors.New("no chapters found in the EPUB book")
+	}
+	return strings.Join(texts, "\n\n"), nil
+}
+
+// epubSpine() function returns the paths of the chapters
+// listed in the spine of the package document.
+func epubSpine(files map[string]*zip.File) ([]string, error) {
+	f, ok := files["META-INF/container.xml"]
+	if !ok {
+		return nil, errors.New("missing META-INF/container.xml")
+	}
+	var container epubContainer
+	if err := decodeZipXML(f, &container); err != nil {
+		return nil, err
+	}
+	if len(container.Rootfiles) == 0 {
+		return nil, errors.New("no package document in META-INF/container.xml")
+	}
+
+	opfPath := container.Rootfiles[0].FullPath
+	f, ok = files[opfPath]
+	if !ok {
+		return nil, fmt.Errorf("missing package document %q", opfPath)
+	}
+	var pkg epubPackage
+	if err := decodeZipXML(f, &pkg); err != nil {
+		return nil, err
+	}
+
+	hrefs := make(map[string]string, len(pkg.Manifest))
+	for _, item := range pkg.Manifest {
+		hrefs[item.ID] = item.Href
+	}
+
+	var chapters []string
+	for _, ref := range pkg.Spine {
+		href, ok := hrefs[ref.IDRef]
+		if !ok || ref.Linear == "no" {
+			continue
+		}
+		// Hrefs are relative to the package document
+		chapters = append(chapters, path.Join(path.Dir(opfPath), href))
+	}
+	return chapters, nil
+}
+
+func decodeZipXML(f *zip.File, v any) error {
+	content, err := readZipFile(f)
+	if err != nil {
+		return err
+	}
+	if err := xml.Unmarshal(content, v); err != nil {
+		return fmt.Errorf("failed to parse %q: %w", f.Name, err)
+	}
+	return nil
+}
+
+func readZipFile(f *zip.File) ([]byte, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %q: %w", f.Name, err)
+	}
+	defer rc.Close()
+	return io.ReadAll(rc)
+}
@@ -0,0 +1,45 @@
+package corpus
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// trailers are commit message lines that are not prose.
+var trailers = []string{"signed-off-by:", "co-authored-by:", "reviewed-by:", "change-id:"}
+
+// ReadGitLog() function returns the lines of the commit messages
+// of the repository at dir, read by invoking git.
+// Blank lines, trailers and merge commits are skipped.
+func ReadGitLog(dir string) ([]string, error) {
+	var stderr bytes.Buffer
+	cmd := exec.Command("git", "-C", dir, "log", "--no-merges", "--format=%B%x00")
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("git log failed: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	var lines []string
+	for _, message := range strings.Split(string(out), "\x00") {
+		for _, line := range strings.Split(message, "\n") {
+			line = strings.Join(strings.Fields(line), " ")
+			if line != "" && !isTrailer(line) {
+				lines = append(lines, line)
+			}
+		}
+	}
+	return lines, nil
+}
+
+func isTrailer(line string) bool {
+	lower := strings.ToLower(line)
+	for _, t := range trailers {
+		if strings.HasPrefix(lower, t) {
+			return true
+		}
+	}
+	return false
+}
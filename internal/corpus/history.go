@@ -0,0 +1,75 @@
+package corpus
+
+import (
+	"bufio"
+	"io"
+	"regexp"
+	"strings"
+)
+
+var (
+	// zshExtended matches the ": <start>:<elapsed>;" prefix
+	// of zsh's EXTENDED_HISTORY format.
+	zshExtended = regexp.MustCompile(`^: *\d+:\d+;`)
+	// bashTimestamp matches the "#<start>" lines
+	// bash writes when HISTTIMEFORMAT is set.
+	bashTimestamp = regexp.MustCompile(`^#\d+$`)
+)
+
+// zshMeta precedes bytes that zsh stores "metafied" in its history.
+const zshMeta = 0x83
+
+// ReadShellHistory() function reads the commands of a bash or zsh
+// history file. Timestamps are dropped and commands continued over
+// several lines with a trailing backslash are joined.
+func ReadShellHistory(r io.Reader) ([]string, error) {
+	var commands []string
+	var current []string
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 1<<20)
+	for scanner.Scan() {
+		line := unmetafy(scanner.Text())
+		if len(current) == 0 {
+			if bashTimestamp.MatchString(line) {
+				continue
+			}
+			line = zshExtended.ReplaceAllString(line, "")
+		}
+
+		if strings.HasSuffix(line, "\\") {
+			current = append(current, strings.TrimSuffix(line, "\\"))
+			continue
+		}
+		current = append(current, line)
+
+		command := strings.Join(strings.Fields(strings.Join(current, " ")), " ")
+		if command != "" {
+			commands = append(commands, command)
+		}
+		current = nil
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return commands, nil
+}
+
+// unmetafy() function restores the bytes zsh escaped in its history.
+func unmetafy(line string) string {
+	if strings.IndexByte(line, zshMeta) < 0 {
+		return line
+	}
+
+	b := make([]byte, 0, len(line))
+	for i := 0; i < len(line); i++ {
+		if line[i] == zshMeta && i+1 < len(line) {
+			i++
+			b = append(b, line[i]^0x20)
+			continue
+		}
+		b = append(b, line[i])
+	}
+	return string(b)
+}
@@ -0,0 +1,34 @@
+package corpus
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReadShellHistory(t *testing.T) {
+	tests := []struct {
+		name    string
+		history string
+		want    []string
+	}{
+		{"bash", "ls -la\n#1700000000\ngit   status\n\n", []string{"ls -la", "git status"}},
+		{"zsh extended", ": 1700000000:0;make test\n:  1700000001:12;go vet ./...\n",
+			[]string{"make test", "go vet ./..."}},
+		{"continued", ": 1700000000:0;docker run \\\n  --rm \\\n  alpine\necho done\n",
+			[]string{"docker run --rm alpine", "echo done"}},
+		// Only the first line of a command carries a timestamp
+		{"continued timestamp lookalike", "echo \\\n#123\n", []string{"echo #123"}},
+		// zsh stores some bytes as 0x83 followed by the byte xor 0x20
+		{"metafied", "echo \x83\xa3\n", []string{"echo \x83"}},
+	}
+	for _, tt := range tests {
+		got, err := ReadShellHistory(strings.NewReader(tt.history))
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package corpus
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// htmlSkipped are elements whose content is not prose.
+var htmlSkipped = map[string]bool{
+	"head": true, "script": true, "style": true, "template": true,
+	"noscript": true, "svg": true, "math": true, "pre": true,
+	"nav": true, "iframe": true, "object": true, "rt": true,
+}
+
+// htmlBlocks are elements that start and end a paragraph.
+var htmlBlocks = map[string]bool{
+	"p": true, "div": true, "hr": true, "li": true,
+	"h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true,
+	"blockquote": true, "section": true, "article": true, "aside": true,
+	"header": true, "footer": true, "main": true, "figure": true,
+	"figcaption": true, "table": true, "tr": true, "td": true, "th": true,
+	"ul": true, "ol": true, "dl": true, "dt": true, "dd": true, "body": true,
+}
+
+// htmlRawText matches scripts and styles, whose content
+// is not escaped and trips up the XML decoder.
+var htmlRawText = regexp.MustCompile(`(?is)<script\b.*?</script\s*>|<style\b.*?</style\s*>`)
+
+// ExtractHTML() function returns the text of an HTML or XHTML document,
+// with a paragraph per block element. Scripts, styles, code and
+// navigation are dropped.
+func ExtractHTML(data []byte) (string, error) {
+	data = htmlRawText.ReplaceAll(data, nil)
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	decoder.Strict = false
+	decoder.AutoClose = xml.HTMLAutoClose
+	decoder.Entity = xml.HTMLEntity
+	// Documents declaring other charsets are read as they are
+	decoder.CharsetReader = func(_ string, r io.Reader) (io.Reader, error) {
+		return r, nil
+	}
+
+	var paragraphs []string
+	var paragraph strings.Builder
+	flush := func() {
+		if text := strings.Join(strings.Fields(paragraph.String()), " "); text != "" {
+			paragraphs = append(paragraphs, text)
+		}
+		paragraph.Reset()
+	}
+
+	skipped := 0
+	for {
+		token, err := decoder.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			// Keep what was read of malformed documents
+			if len(paragraphs) > 0 || paragraph.Len() > 0 {
+				break
+			}
+			return "", err
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			name := strings.ToLower(t.Name.Local)
+			if htmlSkipped[name] {
+				skipped++
+			}
+			if htmlBlocks[name] {
+				flush()
+			}
+			if name == "br" {
+				paragraph.WriteByte(' ')
+			}
+		case xml.EndElement:
+			name := strings.ToLower(t.Name.Local)
+			if htmlSkipped[name] && skipped > 0 {
+				skipped--
+			}
+			if htmlBlocks[name] {
+				flush()
+			}
+		case xml.CharData:
+			if skipped == 0 {
+				paragraph.Write(t)
+			}
+		}
+	}
+	flush()
+
+	return strings.Join(paragraphs, "\n\n"), nil
+}
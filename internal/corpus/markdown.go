@@ -0,0 +1,89 @@
+package corpus
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	// mdBlockPrefix matches headings, quotes and list markers.
+	mdBlockPrefix = regexp.MustCompile(`^\s*(?:#{1,6}\s+|>\s?|[-*+]\s+|\d+[.)]\s+)+`)
+	// mdRule matches horizontal rules and setext heading underlines.
+	mdRule = regexp.MustCompile(`^\s*(?:[-*_=]\s*){3,}$`)
+	// mdReference matches link reference definitions.
+	mdReference = regexp.MustCompile(`^\s*\[[^\]]+\]:\s*\S+`)
+	// mdTableRule matches the rule under a table header.
+	mdTableRule = regexp.MustCompile(`^\s*\|?(?:\s*:?-+:?\s*\|)+\s*:?-*:?\s*$`)
+
+	mdImage    = regexp.MustCompile(`!\[([^\]]*)\]\([^)]*\)`)
+	mdLink     = regexp.MustCompile(`\[([^\]]+)\](?:\([^)]*\)|\[[^\]]*\])`)
+	mdAutolink = regexp.MustCompile(`<(?:https?|mailto):[^>]+>`)
+	mdTag      = regexp.MustCompile(`</?[a-zA-Z][^>]*>`)
+	mdCode     = regexp.MustCompile("`+([^`]*)`+")
+	mdEmphasis = regexp.MustCompile(`(\*{1,3}|_{1,3}|~~)(\S(?:.*?\S)?)(\*{1,3}|_{1,3}|~~)`)
+)
+
+// ExtractMarkdown() function strips Markdown syntax, keeping the text
+// of headings, lists, quotes, links and emphasis. Code blocks,
+// images, HTML tags and link references are dropped.
+func ExtractMarkdown(data []byte) string {
+	var paragraphs []string
+	var paragraph []string
+	flush := func() {
+		if len(paragraph) > 0 {
+			paragraphs = append(paragraphs, strings.Join(paragraph, " "))
+			paragraph = nil
+		}
+	}
+
+	fence := ""
+	for _, line := range strings.Split(string(data), "\n") {
+		trimmed := strings.TrimSpace(line)
+		if fence != "" {
+			if strings.HasPrefix(trimmed, fence) {
+				fence = ""
+			}
+			continue
+		}
+
+		switch {
+		case strings.HasPrefix(trimmed, "```"), strings.HasPrefix(trimmed, "~~~"):
+			flush()
+			fence = trimmed[:3]
+		case trimmed == "", mdRule.MatchString(line), mdTableRule.MatchString(line):
+			flush()
+		case mdReference.MatchString(line):
+		case strings.HasPrefix(line, "    "), strings.HasPrefix(line, "\t"):
+			// Indented code blocks, unless they continue a paragraph
+			if len(paragraph) > 0 {
+				paragraph = append(paragraph, markdownInline(trimmed))
+			}
+		default:
+			if mdBlockPrefix.MatchString(line) {
+				// Headings, list items and quotes start a paragraph of their own
+				flush()
+				trimmed = mdBlockPrefix.ReplaceAllString(line, "")
+				trimmed = strings.TrimRight(strings.TrimSpace(trimmed), "# ")
+			}
+			if text := markdownInline(trimmed); text != "" {
+				paragraph = append(paragraph, text)
+			}
+		}
+	}
+	flush()
+
+	return strings.Join(paragraphs, "\n\n")
+}
+
+// markdownInline() function strips the inline syntax of a line.
+func markdownInline(line string) string {
+	line = mdImage.ReplaceAllString(line, "")
+	line = mdLink.ReplaceAllString(line, "$1")
+	line = mdAutolink.ReplaceAllString(line, "")
+	line = mdTag.ReplaceAllString(line, "")
+	line = mdCode.ReplaceAllString(line, "$1")
+	line = mdEmphasis.ReplaceAllString(line, "$2")
+	line = strings.ReplaceAll(line, "|", " ")
+	line = strings.ReplaceAll(line, `\`, "")
+	return strings.Join(strings.Fields(line), " ")
+}
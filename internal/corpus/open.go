@@ -0,0 +1,128 @@
+package corpus
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/klauspost/compress/gzip"
+	"github.com/klauspost/compress/zstd"
+)
+
+var (
+	gzipMagic = []byte{0x1f, 0x8b}
+	zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+)
+
+// compressions are the extensions of compressed files.
+var compressions = []string{".gz", ".zst"}
+
+// Open() function opens the file at the path, transparently
+// decompressing gzip and zstd files, detected by their magic bytes.
+func Open(path string) (io.ReadCloser, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	r, err := Decompress(file)
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to decompress %q: %w", path, err)
+	}
+	return &readCloser{Reader: r, closers: []io.Closer{r, file}}, nil
+}
+
+// Decompress() function returns a reader decompressing r if it
+// starts with gzip or zstd magic bytes, or reading it as it is.
+func Decompress(r io.Reader) (io.ReadCloser, error) {
+	br := bufio.NewReader(r)
+	head, _ := br.Peek(len(zstdMagic))
+
+	switch {
+	case bytes.HasPrefix(head, gzipMagic):
+		return gzip.NewReader(br)
+	case bytes.HasPrefix(head, zstdMagic):
+		decoder, err := zstd.NewReader(br)
+		if err != nil {
+			return nil, err
+		}
+		return decoder.IOReadCloser(), nil
+	default:
+		return io.NopCloser(br), nil
+	}
+}
+
+// TrimCompression() function removes the extension
+// of a compressed file from the path.
+func TrimCompression(path string) string {
+	for _, ext := range compressions {
+		if strings.EqualFold(filepath.Ext(path), ext) {
+			return strings.TrimSuffix(path, filepath.Ext(path))
+		}
+	}
+	return path
+}
+
+// Expand() function expands glob patterns and directories into the
+// regular files they contain, walking directories in lexical order.
+// Files in directories are kept if match reports true, match may be
+// nil to keep all files. Hidden directories are skipped unless given
+// explicitly.
+func Expand(patterns []string, match func(path string) bool) ([]string, error) {
+	var paths []string
+	for _, pattern := range patterns {
+		roots := []string{pattern}
+		if strings.ContainsAny(pattern, "*?[") {
+			var err error
+			roots, err = filepath.Glob(pattern)
+			if err != nil {
+				return nil, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+			}
+			if len(roots) == 0 {
+				return nil, fmt.Errorf("no files match %q", pattern)
+			}
+		}
+
+		for _, root := range roots {
+			err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+				if err != nil {
+					return err
+				}
+				if d.IsDir() && path != root && strings.HasPrefix(d.Name(), ".") {
+					return filepath.SkipDir
+				}
+				if !d.Type().IsRegular() || (path != root && match != nil && !match(path)) {
+					return nil
+				}
+				paths = append(paths, path)
+				return nil
+			})
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	return paths, nil
+}
+
+// readCloser closes a decompressor along with the file under it.
+type readCloser struct {
+	io.Reader
+	closers []io.Closer
+}
+
+func (rc *readCloser) Close() error {
+	var err error
+	for _, c := range rc.closers {
+		if cerr := c.Close(); err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
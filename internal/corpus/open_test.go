@@ -0,0 +1,101 @@
+package corpus
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// writeFiles() function creates the files, relative to dir.
+func writeFiles(t *testing.T, dir string, files ...string) {
+	t.Helper()
+	for _, name := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(name), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestExpand(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, "a.md", "b.txt", "bin", "sub/c.md", "sub/d.md.gz", ".hidden/e.md")
+	rel := func(paths []string) []string {
+		for i, path := range paths {
+			paths[i], _ = filepath.Rel(dir, path)
+		}
+		return paths
+	}
+
+	tests := []struct {
+		name     string
+		patterns []string
+		match    func(string) bool
+		want     []string
+	}{
+		{"directory", []string{dir}, nil,
+			[]string{"a.md", "b.txt", "bin", "sub/c.md", "sub/d.md.gz"}},
+		{"directory filtered", []string{dir}, IsDocument,
+			[]string{"a.md", "b.txt", "sub/c.md", "sub/d.md.gz"}},
+		{"glob", []string{filepath.Join(dir, "*.md"), filepath.Join(dir, "sub", "*")}, nil,
+			[]string{"a.md", "sub/c.md", "sub/d.md.gz"}},
+		// Files given explicitly are kept whatever match says
+		{"explicit file", []string{filepath.Join(dir, "bin")}, IsDocument,
+			[]string{"bin"}},
+		{"explicit hidden directory", []string{filepath.Join(dir, ".hidden")}, nil,
+			[]string{".hidden/e.md"}},
+	}
+	for _, tt := range tests {
+		got, err := Expand(tt.patterns, tt.match)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if got = rel(got); !reflect.DeepEqual(got, slashed(tt.want)) {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+	}
+
+	if _, err := Expand([]string{filepath.Join(dir, "*.epub")}, nil); err == nil {
+		t.Error("no error for a glob matching nothing")
+	}
+}
+
+// slashed() function converts the slash separated paths to the OS separator.
+func slashed(paths []string) []string {
+	converted := make([]string, len(paths))
+	for i, path := range paths {
+		converted[i] = filepath.FromSlash(path)
+	}
+	return converted
+}
+
+func TestOpenDecompresses(t *testing.T) {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	w.Write([]byte("compressed text"))
+	w.Close()
+	path := filepath.Join(t.TempDir(), "text.txt.gz")
+	if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	data, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "compressed text" {
+		t.Errorf("got %q", data)
+	}
+}
@@ -0,0 +1,50 @@
+package corpus
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	// subtitleTag matches formatting tags such as <i> and <c.yellow>,
+	// and the {\an8} style overrides of SubRip files.
+	subtitleTag = regexp.MustCompile(`</?[a-zA-Z][^>]*>|<\d[^>]*>|\{\\[^}]*\}`)
+	// subtitleNote matches descriptions of sounds such as [door slams].
+	subtitleNote = regexp.MustCompile(`\[[^\]]*\]|\([A-Z ]+\)|♪`)
+)
+
+// ExtractSubtitles() function returns the spoken text of SubRip (.srt)
+// or WebVTT (.vtt) subtitles. Cue numbers, timings, headers, notes,
+// formatting and sound descriptions are dropped. Cues are joined into
+// running text, since sentences often span several of them.
+func ExtractSubtitles(data []byte) string {
+	text := strings.ReplaceAll(string(data), "\r\n", "\n")
+
+	var lines []string
+	for _, block := range strings.Split(text, "\n\n") {
+		cue := strings.Split(strings.Trim(block, "\n"), "\n")
+
+		// Text follows the timing line, blocks without one
+		// are headers, notes, styles or stray numbers
+		timing := -1
+		for i, line := range cue {
+			if strings.Contains(line, "-->") {
+				timing = i
+				break
+			}
+		}
+		if timing < 0 {
+			continue
+		}
+
+		for _, line := range cue[timing+1:] {
+			line = subtitleTag.ReplaceAllString(line, "")
+			line = subtitleNote.ReplaceAllString(line, "")
+			line = strings.TrimLeft(strings.TrimSpace(line), "-– ")
+			if line = strings.Join(strings.Fields(line), " "); line != "" {
+				lines = append(lines, line)
+			}
+		}
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,108 @@
+package adaptive
+
+import (
+	"errors"
+	"time"
+
+	"github.com/abilun/keybon/internal/generator"
+	"github.com/abilun/keybon/internal/typing"
+)
+
+const (
+	// DefaultCandidates is how many words each word is chosen
+	// from, unless SetCandidates is called.
+	DefaultCandidates = 8
+	// latencyWeight balances slowness against the error rate,
+	// which is usually an order of magnitude smaller.
+	latencyWeight = 0.1
+)
+
+// Generator wraps another generator and, out of several
+// candidate words, prefers the ones that contain the
+// characters and bigrams the user is slowest or least
+// accurate at, according to the accumulated key statistics.
+type Generator struct {
+	source     generator.Generator
+	stats      *typing.KeyStats
+	candidates int
+}
+
+// New() function creates an adaptive generator drawing
+// candidate words from the source generator. The statistics
+// are read on every call, so updating them between sessions
+// immediately changes the words.
+func New(source generator.Generator, stats *typing.KeyStats) *Generator {
+	return &Generator{
+		source:     source,
+		stats:      stats,
+		candidates: DefaultCandidates,
+	}
+}
+
+// SetCandidates() function sets how many words are drawn
+// from the source generator to choose each word from.
+func (g *Generator) SetCandidates(n int) error {
+	if n < 1 {
+		return errors.New("number of candidates must be greater than 0")
+	}
+	g.candidates = n
+	return nil
+}
+
+// Seed() function seeds the source generator if it supports it.
+func (g *Generator) Seed(seed int64) {
+	if s, ok := g.source.(generator.Seeder); ok {
+		s.Seed(seed)
+	}
+}
+
+// Next() function draws the candidate words and returns the one
+// with the highest weakness score. The variety comes from the
+// source generator, the bias from keeping the weakest candidate.
+func (g *Generator) Next() (string, error) {
+	var best string
+	bestScore := -1.0
+
+	for i := 0; i < g.candidates; i++ {
+		word, err := g.source.Next()
+		if err != nil {
+			return "", err
+		}
+		if score := g.Score(word); score > bestScore {
+			best, bestScore = word, score
+		}
+	}
+	return best, nil
+}
+
+// Score() function returns how much practicing the word would help:
+// the weakness of its weakest character or bigram. Keys that were
+// never typed get the score of an average key.
+func (g *Generator) Score(word string) float64 {
+	mean := g.stats.MeanLatency()
+	average := g.stats.Total()
+	runes := []rune(word)
+	score := 0.0
+
+	for i := range runes {
+		score = max(score, g.weakness(g.stats.Chars, string(runes[i]), average, mean))
+		if i > 0 {
+			score = max(score, g.weakness(g.stats.Bigrams, string(runes[i-1:i+1]), average, mean))
+		}
+	}
+	return score
+}
+
+// weakness() function combines the error rate and the relative slowness of a key.
+func (g *Generator) weakness(m map[string]*typing.KeyStat, key string, average typing.KeyStat, mean time.Duration) float64 {
+	stat, ok := m[key]
+	if !ok {
+		stat = &average
+	}
+
+	slowness := 1.0
+	if mean > 0 && stat.Timed > 0 {
+		slowness = float64(stat.MeanLatency()) / float64(mean)
+	}
+	return stat.ErrorRate() + latencyWeight*slowness
+}
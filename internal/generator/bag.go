@@ -0,0 +1,40 @@
+package generator
+
+import "math/rand"
+
+// Bag draws items in a random order without repeating any
+// until all were drawn, like drawing from a bag of tiles.
+// The zero value is an empty bag.
+type Bag[T any] struct {
+	indices []int
+}
+
+// Reset() function empties the bag, so it is refilled on the
+// next Draw, e.g. after the items or the filter changed.
+func (b *Bag[T]) Reset() {
+	b.indices = nil
+}
+
+// Draw() function returns the next item of the bag. Once the bag is
+// empty, it is refilled with the items kept by keep, nil keeping all,
+// and shuffled. It returns false if no item is kept.
+func (b *Bag[T]) Draw(rng *rand.Rand, items []T, keep func(T) bool) (T, bool) {
+	if len(b.indices) == 0 {
+		for i, item := range items {
+			if keep == nil || keep(item) {
+				b.indices = append(b.indices, i)
+			}
+		}
+		if len(b.indices) == 0 {
+			var zero T
+			return zero, false
+		}
+		rng.Shuffle(len(b.indices), func(i, j int) {
+			b.indices[i], b.indices[j] = b.indices[j], b.indices[i]
+		})
+	}
+
+	item := items[b.indices[0]]
+	b.indices = b.indices[1:]
+	return item, true
+}
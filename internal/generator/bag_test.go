@@ -0,0 +1,34 @@
+package generator
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestBagDrawsEveryItemOncePerRound(t *testing.T) {
+	items := []int{1, 2, 3, 4, 5, 6}
+	even := func(n int) bool { return n%2 == 0 }
+
+	var b Bag[int]
+	rng := rand.New(rand.NewSource(1))
+	for round := 0; round < 3; round++ {
+		drawn := make(map[int]bool)
+		for i := 0; i < 3; i++ {
+			n, ok := b.Draw(rng, items, even)
+			if !ok {
+				t.Fatal("nothing drawn")
+			}
+			if !even(n) || drawn[n] {
+				t.Fatalf("round %d: drew %d after %v", round, n, drawn)
+			}
+			drawn[n] = true
+		}
+	}
+}
+
+func TestBagWithoutKeptItems(t *testing.T) {
+	var b Bag[string]
+	if _, ok := b.Draw(rand.New(rand.NewSource(1)), []string{"a"}, func(string) bool { return false }); ok {
+		t.Error("drew an item that was not kept")
+	}
+}
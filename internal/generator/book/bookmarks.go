@@ -0,0 +1,66 @@
+package book
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+)
+
+// Bookmarks keeps the position reached in each book between runs.
+// Books are identified by the hash of their content, so a position
+// survives moving or renaming the file.
+type Bookmarks struct {
+	path    string
+	Offsets map[string]int `json:"offsets"`
+}
+
+// DefaultBookmarksPath() function returns the file the bookmarks
+// are kept in, inside the user configuration directory.
+func DefaultBookmarksPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "keybon", "bookmarks.json"), nil
+}
+
+// LoadBookmarks() function reads the bookmarks from a file.
+// A missing file gives no bookmarks.
+func LoadBookmarks(path string) (*Bookmarks, error) {
+	b := &Bookmarks{
+		path:    path,
+		Offsets: make(map[string]int),
+	}
+
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return b, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	if err := json.NewDecoder(file).Decode(b); err != nil {
+		return nil, err
+	}
+	if b.Offsets == nil {
+		b.Offsets = make(map[string]int)
+	}
+	return b, nil
+}
+
+// Save() function writes the bookmarks back to their file,
+// creating its directory if needed.
+func (b *Bookmarks) Save() error {
+	if err := os.MkdirAll(filepath.Dir(b.path), 0o755); err != nil {
+		return err
+	}
+	file, err := os.Create(b.path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return json.NewEncoder(file).Encode(b)
+}
@@ -0,0 +1,201 @@
+package book
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/abilun/keybon/internal/generator"
+	"github.com/abilun/keybon/internal/scanner"
+)
+
+const defaultChunkSize = 250
+
+// Generator walks a text in order, serving it chunk by chunk.
+// Chunks end at paragraph breaks or, once long enough,
+// at the end of a sentence.
+type Generator struct {
+	name      string
+	text      string
+	key       string
+	chunkSize int
+
+	// offset is the start of the chunk being typed,
+	// next the start of the chunk after it
+	offset int
+	next   int
+
+	bookmarks *Bookmarks
+	words     []string
+}
+
+// New() function reads the whole text of the book. The name
+// is used in the attribution of the passages.
+func New(name string, r io.Reader) (*Generator, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Generator{
+		name:      name,
+		text:      string(data),
+		key:       fmt.Sprintf("%x", sha256.Sum256(data)),
+		chunkSize: defaultChunkSize,
+	}, nil
+}
+
+// SetChunkSize() function sets the approximate length of chunks in bytes.
+func (g *Generator) SetChunkSize(size int) error {
+	if size < 1 {
+		return fmt.Errorf("invalid chunk size %d", size)
+	}
+	g.chunkSize = size
+	return nil
+}
+
+// UseBookmarks() function resumes the book from its bookmark
+// and keeps the bookmark updated as chunks are completed.
+func (g *Generator) UseBookmarks(b *Bookmarks) {
+	g.bookmarks = b
+	g.offset = min(b.Offsets[g.key], len(g.text))
+	g.next = g.offset
+}
+
+// Restart() function goes back to the beginning of the book.
+func (g *Generator) Restart() error {
+	g.offset, g.next = 0, 0
+	return g.saveBookmark()
+}
+
+// Progress() function returns the share of the book completed, from 0 to 1.
+func (g *Generator) Progress() float64 {
+	if len(g.text) == 0 {
+		return 1
+	}
+	return float64(g.offset) / float64(len(g.text))
+}
+
+// NextPassage() function returns the chunk of the book to type,
+// the same one until CompletePassage is called. At the end
+// of the book it returns generator.ErrExhausted.
+func (g *Generator) NextPassage() (generator.Passage, error) {
+	text, next := g.chunk(g.offset)
+	if text == "" {
+		g.offset, g.next = len(g.text), len(g.text)
+		return generator.Passage{}, generator.ErrExhausted
+	}
+	g.next = next
+
+	return generator.Passage{
+		Text:        text,
+		Attribution: fmt.Sprintf("%s, %.0f%%", g.name, g.Progress()*100),
+	}, nil
+}
+
+// CompletePassage() function moves the bookmark past
+// the chunk returned by NextPassage and saves it.
+func (g *Generator) CompletePassage() error {
+	if g.next == g.offset {
+		return nil
+	}
+	g.offset = g.next
+	return g.saveBookmark()
+}
+
+// Next() function returns the words of the book one by one.
+// The chunks of the words returned count as completed.
+func (g *Generator) Next() (string, error) {
+	if len(g.words) == 0 {
+		passage, err := g.NextPassage()
+		if err != nil {
+			return "", err
+		}
+		if err := g.CompletePassage(); err != nil {
+			return "", err
+		}
+		g.words = strings.Fields(passage.Text)
+	}
+	word := g.words[0]
+	g.words = g.words[1:]
+	return word, nil
+}
+
+func (g *Generator) saveBookmark() error {
+	if g.bookmarks == nil {
+		return nil
+	}
+	g.bookmarks.Offsets[g.key] = g.offset
+	return g.bookmarks.Save()
+}
+
+// chunk() function returns the normalized text of the chunk starting
+// at the offset and the offset right after it. The chunk ends at the
+// first paragraph break, or at the first sentence end after chunkSize
+// bytes. Without either within twice the chunkSize, it ends at the last
+// sentence end or word seen.
+func (g *Generator) chunk(start int) (string, int) {
+	t := g.text
+	for start < len(t) {
+		r, width := utf8.DecodeRuneInString(t[start:])
+		if !unicode.IsSpace(r) {
+			break
+		}
+		start += width
+	}
+
+	lastSentence, lastSpace := -1, -1
+	end := len(t)
+	for i, r := range t[start:] {
+		pos := start + i
+		if unicode.IsSpace(r) {
+			if r == '\n' && paragraphBreak(t, pos) {
+				end = pos
+				break
+			}
+			if scanner.EndsSentence([]byte(lastWord(t[start:pos]))) {
+				lastSentence = pos
+				if pos-start >= g.chunkSize {
+					end = pos
+					break
+				}
+			}
+			lastSpace = pos
+		}
+		if pos-start >= 2*g.chunkSize {
+			switch {
+			case lastSentence > start:
+				end = lastSentence
+			case lastSpace > start:
+				end = lastSpace
+			default:
+				continue
+			}
+			break
+		}
+	}
+
+	return strings.Join(strings.Fields(t[start:end]), " "), end
+}
+
+// paragraphBreak() function reports whether the newline
+// at pos is followed by a blank line.
+func paragraphBreak(t string, pos int) bool {
+	for _, r := range t[pos+1:] {
+		switch {
+		case r == '\n':
+			return true
+		case !unicode.IsSpace(r):
+			return false
+		}
+	}
+	return false
+}
+
+// lastWord() function returns the text after its last whitespace.
+func lastWord(text string) string {
+	return text[strings.LastIndexFunc(text, unicode.IsSpace)+1:]
+}
@@ -0,0 +1,135 @@
+package book
+
+import (
+	"errors"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/abilun/keybon/internal/generator"
+)
+
+const testBook = `Chapter one
+
+It was a dark night. Mr. Smith stayed in. The rain fell on the roof.
+
+The end.`
+
+// newBook() function returns a generator of the text with small chunks.
+func newBook(t *testing.T, text string, chunkSize int) *Generator {
+	t.Helper()
+	g, err := New("book", strings.NewReader(text))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := g.SetChunkSize(chunkSize); err != nil {
+		t.Fatal(err)
+	}
+	return g
+}
+
+// passages() function returns the texts of all passages, completing each.
+func passages(t *testing.T, g *Generator) []string {
+	t.Helper()
+	var texts []string
+	for {
+		p, err := g.NextPassage()
+		if errors.Is(err, generator.ErrExhausted) {
+			return texts
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		texts = append(texts, p.Text)
+		if err := g.CompletePassage(); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestChunks(t *testing.T) {
+	tests := []struct {
+		name      string
+		text      string
+		chunkSize int
+		want      []string
+	}{
+		{"paragraphs and sentences", testBook, 15, []string{
+			"Chapter one",
+			"It was a dark night.",
+			"Mr. Smith stayed in.",
+			"The rain fell on the roof.",
+			"The end.",
+		}},
+		{"abbreviations", "Please ask Mr. Smith. Then go.", 12, []string{"Please ask Mr. Smith.", "Then go."}},
+		{"whitespace normalized", "  one\n two\t three  ", 100, []string{"one two three"}},
+		// Without sentence ends, chunks end at the last word within twice the size
+		{"no sentence end", "aaa bbb ccc ddd eee", 4, []string{"aaa bbb", "ccc ddd", "eee"}},
+	}
+	for _, tt := range tests {
+		if got := passages(t, newBook(t, tt.text, tt.chunkSize)); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestNextPassageRepeatsUntilCompleted(t *testing.T) {
+	g := newBook(t, testBook, 15)
+	first, _ := g.NextPassage()
+	again, _ := g.NextPassage()
+	if first != again {
+		t.Errorf("got %q, then %q before completing it", first.Text, again.Text)
+	}
+	if err := g.CompletePassage(); err != nil {
+		t.Fatal(err)
+	}
+	if next, _ := g.NextPassage(); next.Text == first.Text {
+		t.Error("completed passage returned again")
+	}
+}
+
+func TestBookmarks(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keybon", "bookmarks.json")
+	open := func(text string) *Generator {
+		t.Helper()
+		bookmarks, err := LoadBookmarks(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		g := newBook(t, text, 15)
+		g.UseBookmarks(bookmarks)
+		return g
+	}
+	next := func(g *Generator) string {
+		t.Helper()
+		p, err := g.NextPassage()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return p.Text
+	}
+
+	g := open(testBook)
+	next(g)
+	if err := g.CompletePassage(); err != nil {
+		t.Fatal(err)
+	}
+	// Fetched, but not completed, so not bookmarked
+	second := next(g)
+
+	if got := next(open(testBook)); got != second {
+		t.Errorf("resumed at %q, want %q", got, second)
+	}
+	if got := next(open("Another book.")); got != "Another book." {
+		t.Errorf("changed content resumed at %q, want its beginning", got)
+	}
+
+	g = open(testBook)
+	if err := g.Restart(); err != nil {
+		t.Fatal(err)
+	}
+	if got := next(open(testBook)); got != "Chapter one" {
+		t.Errorf("restarted book resumed at %q", got)
+	}
+}
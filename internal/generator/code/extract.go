@@ -0,0 +1,133 @@
+package code
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strings"
+)
+
+// goSnippets() function returns the functions and
+// type declarations of a Go file, without their doc comments.
+func goSnippets(name string, src []byte) ([]Snippet, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, name, src, parser.SkipObjectResolution)
+	if err != nil {
+		return nil, err
+	}
+
+	var snippets []Snippet
+	for _, decl := range file.Decls {
+		var declName string
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			declName = d.Name.Name
+			if d.Recv != nil && len(d.Recv.List) > 0 {
+				declName = receiverName(d.Recv.List[0].Type) + "." + declName
+			}
+		case *ast.GenDecl:
+			if d.Tok != token.TYPE || len(d.Specs) == 0 {
+				continue
+			}
+			declName = d.Specs[0].(*ast.TypeSpec).Name.Name
+		default:
+			continue
+		}
+
+		start := fset.Position(decl.Pos()).Offset
+		end := fset.Position(decl.End()).Offset
+		snippets = append(snippets, Snippet{
+			Text: string(src[start:end]),
+			Name: declName,
+			File: name,
+		})
+	}
+	return snippets, nil
+}
+
+// receiverName() function returns the type name of a method receiver.
+func receiverName(expr ast.Expr) string {
+	switch t := expr.(type) {
+	case *ast.StarExpr:
+		return receiverName(t.X)
+	case *ast.IndexExpr:
+		return receiverName(t.X)
+	case *ast.IndexListExpr:
+		return receiverName(t.X)
+	case *ast.Ident:
+		return t.Name
+	}
+	return ""
+}
+
+// blockSnippets() function splits source code of any language into
+// top-level blocks: runs of lines ending at a blank line that is
+// followed by a line without indentation. Blocks made only of
+// comments or of a single line are skipped.
+func blockSnippets(name, src string) []Snippet {
+	lines := strings.Split(src, "\n")
+	var snippets []Snippet
+	var block []string
+
+	flush := func() {
+		if len(block) > 1 && !onlyComments(block) {
+			snippets = append(snippets, Snippet{
+				Text: strings.Join(block, "\n"),
+				Name: blockName(block),
+				File: name,
+			})
+		}
+		block = nil
+	}
+
+	for i, line := range lines {
+		if strings.TrimSpace(line) == "" && topLevelFollows(lines[i+1:]) {
+			flush()
+			continue
+		}
+		block = append(block, line)
+	}
+	flush()
+	return snippets
+}
+
+// topLevelFollows() function reports whether the next
+// non-blank line starts without indentation.
+func topLevelFollows(lines []string) bool {
+	for _, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		return line[0] != ' ' && line[0] != '\t'
+	}
+	return true
+}
+
+func onlyComments(block []string) bool {
+	for _, line := range block {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if !strings.HasPrefix(line, "//") && !strings.HasPrefix(line, "#") &&
+			!strings.HasPrefix(line, "/*") && !strings.HasPrefix(line, "*") {
+			return false
+		}
+	}
+	return true
+}
+
+// blockName() function names a block after its first code line.
+func blockName(block []string) string {
+	for _, line := range block {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if runes := []rune(line); len(runes) > 40 {
+			line = string(runes[:40]) + "…"
+		}
+		return line
+	}
+	return ""
+}
@@ -0,0 +1,171 @@
+package code
+
+import (
+	"errors"
+	"math/rand"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/abilun/keybon/internal/generator"
+)
+
+const (
+	// DefaultMaxLines is the length limit of snippets, in lines,
+	// unless SetMaxLines is called.
+	DefaultMaxLines = 20
+	tabWidth        = 4
+)
+
+// Extensions lists the file extensions recognized as source code.
+var Extensions = map[string]struct{}{
+	".go": {}, ".py": {}, ".js": {}, ".ts": {}, ".jsx": {}, ".tsx": {},
+	".c": {}, ".h": {}, ".cc": {}, ".cpp": {}, ".hpp": {}, ".java": {},
+	".kt": {}, ".rs": {}, ".rb": {}, ".php": {}, ".cs": {}, ".swift": {},
+	".scala": {}, ".lua": {}, ".sh": {}, ".zig": {},
+}
+
+// IsSource() function reports whether the file looks like source code.
+func IsSource(name string) bool {
+	_, ok := Extensions[strings.ToLower(filepath.Ext(name))]
+	return ok
+}
+
+// Snippet is a function or block extracted from a source file.
+type Snippet struct {
+	Text string
+	Name string
+	File string
+}
+
+// Generator serves snippets of source code, indentation
+// and newlines included, in a random order without repeating
+// until all were served.
+type Generator struct {
+	snippets []Snippet
+	maxLines int
+
+	bag   generator.Bag[Snippet]
+	words []string
+	rng   *rand.Rand
+}
+
+func New() *Generator {
+	return &Generator{
+		maxLines: DefaultMaxLines,
+		rng:      rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+// Seed() function makes the order of the snippets reproducible.
+func (g *Generator) Seed(seed int64) {
+	g.rng = rand.New(rand.NewSource(seed))
+	g.bag.Reset()
+}
+
+// SetMaxLines() function skips snippets longer than n lines.
+func (g *Generator) SetMaxLines(n int) error {
+	if n < 1 {
+		return errors.New("max lines must be greater than 0")
+	}
+	g.maxLines = n
+	g.bag.Reset()
+	return nil
+}
+
+// AddFile() function extracts the snippets of a source file:
+// Go files are parsed, other languages are split by heuristics.
+func (g *Generator) AddFile(name string, src []byte) error {
+	var snippets []Snippet
+	if strings.ToLower(filepath.Ext(name)) == ".go" {
+		s, err := goSnippets(name, src)
+		if err != nil {
+			return err
+		}
+		snippets = s
+	} else {
+		snippets = blockSnippets(name, string(src))
+	}
+
+	for _, s := range snippets {
+		s.Text = normalize(s.Text)
+		if s.Text != "" {
+			g.snippets = append(g.snippets, s)
+		}
+	}
+	g.bag.Reset()
+	return nil
+}
+
+// NextPassage() function returns the next snippet, named after its file and declaration.
+func (g *Generator) NextPassage() (generator.Passage, error) {
+	s, err := g.nextSnippet()
+	if err != nil {
+		return generator.Passage{}, err
+	}
+	return generator.Passage{
+		Text:        s.Text,
+		Attribution: filepath.Base(s.File) + ": " + s.Name,
+	}, nil
+}
+
+// Next() function returns the tokens of the snippets one by one.
+func (g *Generator) Next() (string, error) {
+	if len(g.words) == 0 {
+		s, err := g.nextSnippet()
+		if err != nil {
+			return "", err
+		}
+		g.words = strings.Fields(s.Text)
+	}
+	word := g.words[0]
+	g.words = g.words[1:]
+	return word, nil
+}
+
+// nextSnippet() function draws the next snippet from a shuffled bag
+// of the short enough snippets, refilling the bag once it is empty.
+func (g *Generator) nextSnippet() (Snippet, error) {
+	s, ok := g.bag.Draw(g.rng, g.snippets, func(s Snippet) bool {
+		return strings.Count(s.Text, "\n")+1 <= g.maxLines
+	})
+	if !ok {
+		return Snippet{}, errors.New("no code snippets of the allowed length")
+	}
+	return s, nil
+}
+
+// normalize() function expands tabs, trims trailing whitespace,
+// collapses runs of blank lines and removes the common indentation.
+func normalize(text string) string {
+	lines := strings.Split(strings.ReplaceAll(text, "\t", strings.Repeat(" ", tabWidth)), "\n")
+
+	var kept []string
+	for _, line := range lines {
+		line = strings.TrimRight(line, " \r")
+		if line == "" && (len(kept) == 0 || kept[len(kept)-1] == "") {
+			continue
+		}
+		kept = append(kept, line)
+	}
+	for len(kept) > 0 && kept[len(kept)-1] == "" {
+		kept = kept[:len(kept)-1]
+	}
+
+	indent := -1
+	for _, line := range kept {
+		if line == "" {
+			continue
+		}
+		n := len(line) - len(strings.TrimLeft(line, " "))
+		if indent < 0 || n < indent {
+			indent = n
+		}
+	}
+	for i, line := range kept {
+		if len(line) >= indent && indent > 0 {
+			kept[i] = line[indent:]
+		}
+	}
+	return strings.Join(kept, "\n")
+}
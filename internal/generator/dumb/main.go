@@ -1,42 +1,216 @@
 package dumb
 
 import (
+	"bufio"
 	"errors"
 	"io"
 	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/abilun/keybon/internal/scanner"
 )
 
+// Generator samples words from a word list, with the probability
+// of each word proportional to its frequency.
+//
+// Lines of the form "word<TAB>count" give the frequency explicitly.
+// Other lines are split into words: in a plain list, where every word
+// appears once, the words are ranked by their order and weighted
+// by Zipf's law; in running text they are weighted by occurrences.
 type Generator struct {
-	words []string
+	counts   map[string]float64
+	ranks    map[string]int
+	explicit bool
+	top      int
+	config   scanner.TextScannerConfig
+
+	words      []string
+	cumulative []float64
+	rng        *rand.Rand
 }
 
 func (g *Generator) Next() (string, error) {
 	if len(g.words) == 0 {
 		return "", errors.New("no words to generate")
 	}
-	return g.words[rand.Intn(len(g.words))], nil
+	r := g.rng.Float64() * g.cumulative[len(g.cumulative)-1]
+	i := sort.Search(len(g.cumulative), func(i int) bool {
+		return g.cumulative[i] > r
+	})
+	return g.words[min(i, len(g.words)-1)], nil
 }
 
 func New() *Generator {
-	return &Generator{}
+	return &Generator{
+		counts: make(map[string]float64),
+		ranks:  make(map[string]int),
+		config: scanner.DefaultConfig(scanner.ModeLetters),
+		rng:    rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+// Seed() function makes the generated words reproducible.
+func (g *Generator) Seed(seed int64) {
+	g.rng = rand.New(rand.NewSource(seed))
+}
+
+// SetMode() function sets the scanner mode words are split with.
+// It should be called before Fill.
+func (g *Generator) SetMode(mode scanner.Mode) {
+	normalization := g.config.Normalization
+	g.config = scanner.DefaultConfig(mode)
+	g.config.Normalization = normalization
 }
 
+// SetNormalization() function sets the normalization
+// applied to the words. It should be called before Fill.
+func (g *Generator) SetNormalization(normalization scanner.Normalization) error {
+	if _, err := normalization.Pipeline(); err != nil {
+		return err
+	}
+	g.config.Normalization = normalization
+	return nil
+}
+
+// SetTop() function restricts the words to the n most frequent ones.
+// Zero means no restriction.
+func (g *Generator) SetTop(n int) error {
+	if n < 0 {
+		return errors.New("top must not be negative")
+	}
+	g.top = n
+	g.rank()
+	return nil
+}
+
+// Fill() function adds the words of the reader,
+// split with the tokenizer of the mode.
 func (g *Generator) Fill(r io.Reader) error {
-	scanner, err := scanner.New(r)
+	return g.FillWith(r, nil)
+}
+
+// FillWith() function adds the words of the reader like Fill, splitting
+// them with the tokenizer instead, or the one of the mode when nil.
+func (g *Generator) FillWith(r io.Reader, tokenizer scanner.Tokenizer) error {
+	config := g.config
+	config.Tokenizer = tokenizer
+
+	reader := bufio.NewReader(r)
+	for {
+		line, err := reader.ReadString('\n')
+		if line != "" {
+			if fillErr := g.fillLine(line, config); fillErr != nil {
+				return fillErr
+			}
+		}
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	g.rank()
+	return nil
+}
+
+// fillLine() function adds a "word<TAB>count" entry or the words
+// of a line, split with the config.
+func (g *Generator) fillLine(line string, config scanner.TextScannerConfig) error {
+	if word, count, ok := strings.Cut(strings.TrimSpace(line), "\t"); ok {
+		words, err := scanWords(word, config)
+		if err != nil {
+			return err
+		}
+		n, convErr := strconv.ParseFloat(strings.TrimSpace(count), 64)
+		if convErr == nil && n > 0 && len(words) == 1 {
+			g.add(words[0], n)
+			g.explicit = true
+			return nil
+		}
+	}
+
+	words, err := scanWords(line, config)
 	if err != nil {
 		return err
 	}
+	for _, word := range words {
+		g.add(word, 1)
+	}
+	return nil
+}
+
+func (g *Generator) add(word string, count float64) {
+	if _, ok := g.ranks[word]; !ok {
+		g.ranks[word] = len(g.ranks) + 1
+	}
+	g.counts[word] += count
+}
 
-	for scanner.Scan() {
-		word := scanner.Text()
-		g.words = append(g.words, word)
+// rank() function orders the words by frequency, applies
+// the top cutoff and prepares the cumulative weights.
+func (g *Generator) rank() {
+	plainList := !g.explicit
+	for _, count := range g.counts {
+		if count != 1 {
+			plainList = false
+			break
+		}
 	}
 
-	if err := scanner.Err(); err != nil {
-		return err
+	weight := func(word string) float64 {
+		if plainList {
+			return 1 / float64(g.ranks[word])
+		}
+		return g.counts[word]
 	}
 
-	return nil
+	words := make([]string, 0, len(g.counts))
+	for word := range g.counts {
+		words = append(words, word)
+	}
+	sort.Slice(words, func(i, j int) bool {
+		wi, wj := weight(words[i]), weight(words[j])
+		if wi != wj {
+			return wi > wj
+		}
+		return g.ranks[words[i]] < g.ranks[words[j]]
+	})
+	if g.top > 0 && len(words) > g.top {
+		words = words[:g.top]
+	}
+
+	g.words = words
+	g.cumulative = make([]float64, len(words))
+	total := 0.0
+	for i, word := range words {
+		total += weight(word)
+		g.cumulative[i] = total
+	}
+}
+
+// scanWords() function splits the text into words with the given config.
+// Sentence boundaries are dropped, a word list has no use for them.
+func scanWords(text string, config scanner.TextScannerConfig) ([]string, error) {
+	ts, err := scanner.NewWithConfig(strings.NewReader(text), config)
+	if err != nil {
+		return nil, err
+	}
+
+	var words []string
+	for ts.Scan() {
+		if word := ts.Text(); word != scanner.SentenceBoundary {
+			words = append(words, word)
+		}
+	}
+
+	if err := ts.Err(); err != nil {
+		return nil, err
+	}
+
+	return words, nil
 }
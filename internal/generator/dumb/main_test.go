@@ -0,0 +1,103 @@
+package dumb
+
+import (
+	"bytes"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// commaTokenizer splits the text at commas, keeping spaces in tokens.
+type commaTokenizer struct{}
+
+func (commaTokenizer) Split(data []byte, atEOF bool) (int, []byte, error) {
+	if i := bytes.IndexByte(data, ','); i >= 0 {
+		return i + 1, data[:i], nil
+	}
+	if atEOF && len(data) > 0 {
+		return len(data), data, nil
+	}
+	return 0, nil, nil
+}
+
+func TestFillWithTokenizer(t *testing.T) {
+	g := New()
+	if err := g.FillWith(strings.NewReader("New York,Los Angeles"), commaTokenizer{}); err != nil {
+		t.Fatal(err)
+	}
+	words := append([]string(nil), g.words...)
+	sort.Strings(words)
+	// The lowercasing of the default mode still applies
+	if want := []string{"los angeles", "new york"}; !reflect.DeepEqual(words, want) {
+		t.Errorf("words = %q, want %q", words, want)
+	}
+}
+
+// counts() function returns how often each word is generated in n draws.
+func counts(t *testing.T, g *Generator, n int) map[string]int {
+	t.Helper()
+	seen := make(map[string]int)
+	for i := 0; i < n; i++ {
+		word, err := g.Next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		seen[word]++
+	}
+	return seen
+}
+
+func TestNextFavorsTopRankedWords(t *testing.T) {
+	tests := []struct {
+		name string
+		list string
+	}{
+		{"plain list", "the\nof\nand\nto\nin\n"},
+		{"counts", "the\t500\nof\t300\nand\t200\nto\t100\nin\t50\n"},
+	}
+	for _, tt := range tests {
+		g := New()
+		g.Seed(1)
+		if err := g.Fill(strings.NewReader(tt.list)); err != nil {
+			t.Fatal(err)
+		}
+		seen := counts(t, g, 10000)
+		for _, word := range []string{"of", "and", "to", "in"} {
+			if seen[word] >= seen["the"] {
+				t.Errorf("%s: %q drawn %d times, more than the top word %d times",
+					tt.name, word, seen[word], seen["the"])
+			}
+		}
+	}
+}
+
+func TestSetTop(t *testing.T) {
+	g := New()
+	g.Seed(1)
+	if err := g.Fill(strings.NewReader("the\nof\nand\nto\nin\n")); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.SetTop(3); err != nil {
+		t.Fatal(err)
+	}
+	seen := counts(t, g, 1000)
+	for _, word := range []string{"to", "in"} {
+		if seen[word] > 0 {
+			t.Errorf("%q below the top 3 drawn %d times", word, seen[word])
+		}
+	}
+	if len(seen) != 3 {
+		t.Errorf("drew %v, want the top 3 words", seen)
+	}
+
+	if err := g.SetTop(-1); err == nil {
+		t.Error("no error for a negative top")
+	}
+}
+
+func TestNextWithoutWords(t *testing.T) {
+	if _, err := New().Next(); err == nil {
+		t.Error("no error without words")
+	}
+}
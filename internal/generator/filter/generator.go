@@ -0,0 +1,173 @@
+package filter
+
+import (
+	"errors"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/abilun/keybon/internal/generator"
+)
+
+// maxAttempts bounds the number of words drawn from the inner
+// generator, so impossible constraints fail instead of looping forever.
+const maxAttempts = 1000
+
+// ErrNoMatch is returned when no word satisfying
+// the constraints was found within the attempts limit.
+var ErrNoMatch = errors.New("no word satisfies the constraints")
+
+// Predicate reports whether a word may be generated.
+type Predicate func(word string) bool
+
+// Generator is a decorator that keeps pulling words
+// from the inner generator until one is accepted.
+type Generator struct {
+	inner  generator.Generator
+	accept Predicate
+}
+
+// New() function wraps the generator so that
+// it only returns words accepted by the predicate.
+func New(inner generator.Generator, accept Predicate) *Generator {
+	return &Generator{
+		inner:  inner,
+		accept: accept,
+	}
+}
+
+// Next() function returns the next accepted word of the inner generator.
+func (g *Generator) Next() (string, error) {
+	return next(g.inner, g.accept)
+}
+
+// Seed() function seeds the inner generator if it supports it.
+func (g *Generator) Seed(seed int64) {
+	seedInner(g.inner, seed)
+}
+
+// NoRepeat is a decorator that skips words
+// returned within the last N words.
+type NoRepeat struct {
+	inner  generator.Generator
+	recent []string
+	n      int
+}
+
+// NewNoRepeat() function wraps the generator so that
+// a word does not repeat within the last n words.
+func NewNoRepeat(inner generator.Generator, n int) *NoRepeat {
+	return &NoRepeat{
+		inner:  inner,
+		recent: make([]string, 0, n),
+		n:      n,
+	}
+}
+
+// Next() function returns the next word that is not among the recent ones.
+func (g *NoRepeat) Next() (string, error) {
+	word, err := next(g.inner, func(word string) bool {
+		for _, r := range g.recent {
+			if r == word {
+				return false
+			}
+		}
+		return true
+	})
+	if err != nil {
+		return "", err
+	}
+
+	if g.n > 0 {
+		if len(g.recent) == g.n {
+			g.recent = g.recent[1:]
+		}
+		g.recent = append(g.recent, word)
+	}
+	return word, nil
+}
+
+// Seed() function seeds the inner generator if it supports it.
+func (g *NoRepeat) Seed(seed int64) {
+	seedInner(g.inner, seed)
+}
+
+// All() function accepts words accepted by every predicate.
+// Combining predicates this way keeps a single attempts limit
+// instead of multiplying it by nesting decorators.
+func All(predicates ...Predicate) Predicate {
+	return func(word string) bool {
+		for _, p := range predicates {
+			if !p(word) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// MinLength() function accepts words of at least n characters.
+func MinLength(n int) Predicate {
+	return func(word string) bool {
+		return utf8.RuneCountInString(word) >= n
+	}
+}
+
+// MaxLength() function accepts words of at most n characters.
+func MaxLength(n int) Predicate {
+	return func(word string) bool {
+		return utf8.RuneCountInString(word) <= n
+	}
+}
+
+// AllowedChars() function accepts words made only of the given characters.
+func AllowedChars(chars string) Predicate {
+	return func(word string) bool {
+		for _, r := range word {
+			if !strings.ContainsRune(chars, r) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// RequiredChars() function accepts words
+// containing at least one of the given characters.
+func RequiredChars(chars string) Predicate {
+	return func(word string) bool {
+		return strings.ContainsAny(word, chars)
+	}
+}
+
+// Banned() function rejects the given words.
+func Banned(words []string) Predicate {
+	banned := make(map[string]struct{}, len(words))
+	for _, w := range words {
+		banned[w] = struct{}{}
+	}
+	return func(word string) bool {
+		_, ok := banned[word]
+		return !ok
+	}
+}
+
+// next() function draws words until one is accepted.
+func next(inner generator.Generator, accept Predicate) (string, error) {
+	for i := 0; i < maxAttempts; i++ {
+		word, err := inner.Next()
+		if err != nil {
+			return "", err
+		}
+		if accept(word) {
+			return word, nil
+		}
+	}
+	return "", ErrNoMatch
+}
+
+// seedInner() function seeds the generator if it supports it.
+func seedInner(g generator.Generator, seed int64) {
+	if s, ok := g.(generator.Seeder); ok {
+		s.Seed(seed)
+	}
+}
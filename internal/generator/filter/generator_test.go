@@ -0,0 +1,105 @@
+package filter
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// words is a generator cycling through its words.
+type words struct {
+	list  []string
+	calls int
+}
+
+func (w *words) Next() (string, error) {
+	word := w.list[w.calls%len(w.list)]
+	w.calls++
+	return word, nil
+}
+
+func TestPredicates(t *testing.T) {
+	tests := []struct {
+		name   string
+		accept Predicate
+		want   []string
+	}{
+		// Lengths are counted in characters, not bytes
+		{"min length", MinLength(5), []string{"keyboard"}},
+		{"max length", MaxLength(4), []string{"a", "asdf", "état"}},
+		{"allowed chars", AllowedChars("asdf"), []string{"a", "asdf"}},
+		{"required chars", RequiredChars("ty"), []string{"état", "keyboard"}},
+		{"banned", Banned([]string{"a", "asdf"}), []string{"état", "keyboard"}},
+		{"all", All(MinLength(2), MaxLength(4)), []string{"asdf", "état"}},
+		{"all of none", All(), []string{"a", "asdf", "état", "keyboard"}},
+	}
+	for _, tt := range tests {
+		var got []string
+		for _, word := range []string{"a", "asdf", "état", "keyboard"} {
+			if tt.accept(word) {
+				got = append(got, word)
+			}
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: accepted %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestNextSkipsRejectedWords(t *testing.T) {
+	g := New(&words{list: []string{"a", "bb", "ccc"}}, MinLength(2))
+	var got []string
+	for i := 0; i < 4; i++ {
+		word, err := g.Next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, word)
+	}
+	if want := []string{"bb", "ccc", "bb", "ccc"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestNextGivesUp(t *testing.T) {
+	inner := &words{list: []string{"word"}}
+	g := New(inner, func(string) bool { return false })
+	if _, err := g.Next(); !errors.Is(err, ErrNoMatch) {
+		t.Errorf("Next() error = %v, want %v", err, ErrNoMatch)
+	}
+	if inner.calls != maxAttempts {
+		t.Errorf("drew %d words, want %d", inner.calls, maxAttempts)
+	}
+}
+
+func TestNoRepeat(t *testing.T) {
+	tests := []struct {
+		n    int
+		want []string
+	}{
+		{0, []string{"a", "a", "b", "a", "b", "c"}},
+		{1, []string{"a", "b", "a", "b", "c", "a"}},
+		{2, []string{"a", "b", "c", "a", "b", "c"}},
+	}
+	for _, tt := range tests {
+		g := NewNoRepeat(&words{list: []string{"a", "a", "b", "a", "b", "c"}}, tt.n)
+		var got []string
+		for range tt.want {
+			word, err := g.Next()
+			if err != nil {
+				t.Fatal(err)
+			}
+			got = append(got, word)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("n = %d: got %q, want %q", tt.n, got, tt.want)
+		}
+	}
+
+	// A single word can never follow itself
+	g := NewNoRepeat(&words{list: []string{"a"}}, 1)
+	g.Next()
+	if _, err := g.Next(); !errors.Is(err, ErrNoMatch) {
+		t.Errorf("Next() error = %v, want %v", err, ErrNoMatch)
+	}
+}
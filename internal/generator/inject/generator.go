@@ -0,0 +1,200 @@
+package inject
+
+import (
+	"math/rand"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/abilun/keybon/internal/generator"
+)
+
+// Rates at which the characters are injected, roughly
+// following their frequency in English prose.
+const (
+	minSentence   = 4
+	maxSentence   = 14
+	commaRate     = 0.12
+	quoteRate     = 0.04
+	numberRate    = 0.08
+	questionRate  = 0.1
+	exclaimRate   = 0.05
+	capitalRate   = 0.15
+	maxQuoteWords = 4
+)
+
+// Options selects which characters are injected.
+type Options struct {
+	Punctuation bool
+	Numbers     bool
+	Capitals    bool
+	// AllowedChars, if not empty, are the only characters injected
+	AllowedChars string
+}
+
+// Generator is a decorator that turns the plain lowercase
+// words of the inner generator into realistic text with
+// sentence punctuation, quoted phrases, numbers and capitals.
+// Characters are only injected if they are among the AllowedChars
+// and, when the inner generator is a KeyRestricter, its allowed keys.
+type Generator struct {
+	inner generator.Generator
+	opts  Options
+	rng   *rand.Rand
+
+	// words left until the end of the current sentence
+	sentenceLeft int
+	sentenceLen  int
+	// words left until the closing quote, 0 if not quoting
+	quoteLeft int
+}
+
+// New() function wraps the generator to inject the selected characters.
+func New(inner generator.Generator, opts Options) *Generator {
+	return &Generator{
+		inner: inner,
+		opts:  opts,
+		rng:   rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+// Seed() function makes the injection reproducible.
+// The inner generator is seeded as well if it supports it.
+func (g *Generator) Seed(seed int64) {
+	g.rng = rand.New(rand.NewSource(seed))
+	if s, ok := g.inner.(generator.Seeder); ok {
+		s.Seed(seed)
+	}
+}
+
+// AllowedKeys() function passes through the keys allowed by the
+// inner generator, or nil if it does not restrict them.
+func (g *Generator) AllowedKeys() []string {
+	if r, ok := g.inner.(generator.KeyRestricter); ok {
+		return r.AllowedKeys()
+	}
+	return nil
+}
+
+// Next() function returns the next word with the injected characters.
+func (g *Generator) Next() (string, error) {
+	word, err := g.word()
+	if err != nil {
+		return "", err
+	}
+
+	if !g.opts.Punctuation {
+		if g.opts.Capitals && g.rng.Float64() < capitalRate {
+			word = g.capitalize(word)
+		}
+		return word, nil
+	}
+
+	if g.sentenceLeft == 0 {
+		g.sentenceLen = minSentence + g.rng.Intn(maxSentence-minSentence+1)
+		g.sentenceLeft = g.sentenceLen
+		if g.opts.Capitals {
+			word = g.capitalize(word)
+		}
+	}
+	g.sentenceLeft--
+	last := g.sentenceLeft == 0
+	first := g.sentenceLeft == g.sentenceLen-1
+
+	var b strings.Builder
+	if g.quoteLeft == 0 && !last && g.rng.Float64() < quoteRate && g.allows(`"`) {
+		// The quote spans this and at least one more word of the sentence
+		g.quoteLeft = min(2+g.rng.Intn(maxQuoteWords-1), g.sentenceLeft+1)
+		b.WriteRune('"')
+	}
+	b.WriteString(word)
+
+	closing := false
+	if g.quoteLeft > 0 {
+		g.quoteLeft--
+		closing = g.quoteLeft == 0
+	}
+
+	switch {
+	case last:
+		if end := g.sentenceEnd(); g.allows(end) {
+			b.WriteString(end)
+		}
+	case !first && !closing && g.rng.Float64() < commaRate && g.allows(","):
+		b.WriteRune(',')
+	}
+	if closing {
+		b.WriteRune('"')
+	}
+	return b.String(), nil
+}
+
+// word() function returns a word of the inner generator or a number.
+func (g *Generator) word() (string, error) {
+	if g.opts.Numbers && g.rng.Float64() < numberRate {
+		if number := g.number(); g.allows(number) {
+			return number, nil
+		}
+	}
+	return g.inner.Next()
+}
+
+// allows() function reports whether the characters may be injected.
+func (g *Generator) allows(chars string) bool {
+	var keys []string
+	if r, ok := g.inner.(generator.KeyRestricter); ok {
+		keys = r.AllowedKeys()
+	}
+	for _, r := range chars {
+		if g.opts.AllowedChars != "" && !strings.ContainsRune(g.opts.AllowedChars, r) {
+			return false
+		}
+		if keys != nil && !slices.Contains(keys, string(r)) {
+			return false
+		}
+	}
+	return true
+}
+
+// capitalize() function capitalizes the word if its capital is allowed.
+func (g *Generator) capitalize(word string) string {
+	capitalized := capitalize(word)
+	r, _ := utf8.DecodeRuneInString(capitalized)
+	if !g.allows(string(r)) {
+		return word
+	}
+	return capitalized
+}
+
+// number() function returns a small number, sometimes a year.
+func (g *Generator) number() string {
+	if g.rng.Intn(4) == 0 {
+		return strconv.Itoa(1900 + g.rng.Intn(130))
+	}
+	return strconv.Itoa(g.rng.Intn(1000))
+}
+
+// sentenceEnd() function returns the punctuation ending a sentence.
+func (g *Generator) sentenceEnd() string {
+	r := g.rng.Float64()
+	switch {
+	case r < questionRate:
+		return "?"
+	case r < questionRate+exclaimRate:
+		return "!"
+	default:
+		return "."
+	}
+}
+
+// capitalize() function uppercases the first letter of the word.
+func capitalize(word string) string {
+	r, size := utf8.DecodeRuneInString(word)
+	if r == utf8.RuneError {
+		return word
+	}
+	return string(unicode.ToUpper(r)) + word[size:]
+}
@@ -0,0 +1,77 @@
+package inject
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/abilun/keybon/internal/generator/lesson"
+	"github.com/abilun/keybon/internal/typing"
+)
+
+// words is a generator cycling through its words.
+type words struct {
+	list []string
+	i    int
+}
+
+func (w *words) Next() (string, error) {
+	word := w.list[w.i%len(w.list)]
+	w.i++
+	return word, nil
+}
+
+// injected() function returns the characters of n words of the generator.
+func injected(t *testing.T, g *Generator, n int) string {
+	t.Helper()
+	g.Seed(1)
+	var b strings.Builder
+	for i := 0; i < n; i++ {
+		word, err := g.Next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		b.WriteString(word)
+	}
+	return b.String()
+}
+
+var allOptions = Options{Punctuation: true, Numbers: true, Capitals: true}
+
+func TestInjects(t *testing.T) {
+	g := New(&words{list: []string{"fall", "sad", "flask"}}, allOptions)
+	text := injected(t, g, 1000)
+	for _, chars := range []string{".", ",", `"`, "0123456789", "FS"} {
+		if !strings.ContainsAny(text, chars) {
+			t.Errorf("none of %q injected", chars)
+		}
+	}
+}
+
+func TestRespectsLessonKeys(t *testing.T) {
+	// Without statistics only the initial keys of the lesson are unlocked
+	l := lesson.New(&words{list: []string{"fall", "sad", "flask", "jade"}}, typing.NewKeyStats())
+	keys := strings.Join(l.AllowedKeys(), "")
+
+	g := New(l, allOptions)
+	for _, r := range injected(t, g, 1000) {
+		if !strings.ContainsRune(keys, r) {
+			t.Fatalf("injected %q, which the lesson has locked", r)
+		}
+	}
+}
+
+func TestRespectsAllowedChars(t *testing.T) {
+	opts := allOptions
+	opts.AllowedChars = "adflsk.1"
+	g := New(&words{list: []string{"fall", "sad", "flask"}}, opts)
+
+	text := injected(t, g, 1000)
+	for _, r := range text {
+		if !strings.ContainsRune(opts.AllowedChars, r) {
+			t.Fatalf("injected %q, which is not allowed", r)
+		}
+	}
+	if !strings.Contains(text, ".") {
+		t.Error("no allowed sentence ends injected")
+	}
+}
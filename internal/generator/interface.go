@@ -1,5 +1,41 @@
 package generator
 
+import "errors"
+
+// ErrExhausted is returned by generators that reached
+// the end of their text and cannot produce any more.
+var ErrExhausted = errors.New("generator is exhausted")
+
 type Generator interface {
 	Next() (string, error)
 }
+
+// Seeder is implemented by generators whose randomness
+// can be seeded to make the generated text reproducible.
+type Seeder interface {
+	Seed(seed int64)
+}
+
+// KeyRestricter is implemented by generators
+// that only use a subset of the keyboard keys.
+type KeyRestricter interface {
+	AllowedKeys() []string
+}
+
+// Passage is a text to be typed at once, with its origin.
+type Passage struct {
+	Text        string
+	Attribution string
+}
+
+// Passager is implemented by generators that produce
+// whole passages instead of a number of separate words.
+type Passager interface {
+	NextPassage() (Passage, error)
+}
+
+// Completer is implemented by passage generators that keep
+// track of the passages that were typed to the end.
+type Completer interface {
+	CompletePassage() error
+}
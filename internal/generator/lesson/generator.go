@@ -0,0 +1,153 @@
+package lesson
+
+import (
+	"errors"
+	"math/rand"
+	"strings"
+	"time"
+
+	"github.com/abilun/keybon/internal/generator"
+	"github.com/abilun/keybon/internal/typing"
+)
+
+// DefaultOrder is the order keys are unlocked in on keyboard.En:
+// the home row first, then the most frequent English letters.
+var DefaultOrder = strings.Split("fjdkslaehgirutonywcmvpbxqz", "")
+
+const (
+	defaultInitial        = 8
+	defaultTargetWPM      = 35
+	defaultTargetAccuracy = 95
+	// minSamples is how many times a key must be typed
+	// before its speed and accuracy are taken into account.
+	minSamples = 20
+	// maxAttempts bounds the number of words drawn from the source
+	// before a word is made up from the unlocked letters instead.
+	maxAttempts = 100
+)
+
+// Generator is a lesson that only allows a small set of keys at first
+// and unlocks the next key in the order once the user reaches
+// the target speed and accuracy on every unlocked key.
+type Generator struct {
+	source generator.Generator
+	stats  *typing.KeyStats
+
+	order          []string
+	initial        int
+	targetWPM      float64
+	targetAccuracy float64
+
+	rng *rand.Rand
+}
+
+// New() function creates a lesson drawing words from the source generator.
+// Progress is derived from the statistics on every call, so updating them
+// after a session may unlock the next key.
+func New(source generator.Generator, stats *typing.KeyStats) *Generator {
+	return &Generator{
+		source:         source,
+		stats:          stats,
+		order:          DefaultOrder,
+		initial:        defaultInitial,
+		targetWPM:      defaultTargetWPM,
+		targetAccuracy: defaultTargetAccuracy,
+		rng:            rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+// SetTargets() function sets the speed in words per minute
+// and the accuracy in percent required to unlock the next key.
+func (g *Generator) SetTargets(wpm, accuracy float64) error {
+	if wpm <= 0 || accuracy <= 0 || accuracy > 100 {
+		return errors.New("invalid lesson targets")
+	}
+	g.targetWPM = wpm
+	g.targetAccuracy = accuracy
+	return nil
+}
+
+// Seed() function makes the lesson reproducible.
+// The source generator is seeded as well if it supports it.
+func (g *Generator) Seed(seed int64) {
+	g.rng = rand.New(rand.NewSource(seed))
+	if s, ok := g.source.(generator.Seeder); ok {
+		s.Seed(seed)
+	}
+}
+
+// AllowedKeys() function returns the unlocked keys. The first keys
+// of the order are always unlocked, each next one once all
+// the previous keys are mastered.
+func (g *Generator) AllowedKeys() []string {
+	unlocked := min(g.initial, len(g.order))
+	for unlocked < len(g.order) && g.mastered(g.order[:unlocked]) {
+		unlocked++
+	}
+	return g.order[:unlocked]
+}
+
+// mastered() function reports whether every key was typed
+// often enough at the target speed and accuracy.
+func (g *Generator) mastered(keys []string) bool {
+	// A word is 5 characters, so the target speed gives the time per key
+	targetLatency := time.Duration(float64(time.Minute) / (g.targetWPM * 5))
+
+	for _, key := range keys {
+		stat, ok := g.stats.Chars[key]
+		if !ok || stat.Count < minSamples {
+			return false
+		}
+		accuracy := float64(stat.Count-stat.Errors) / float64(stat.Count) * 100
+		if accuracy < g.targetAccuracy || stat.MeanLatency() > targetLatency {
+			return false
+		}
+	}
+	return true
+}
+
+// Next() function returns a word from the source generator that
+// contains only unlocked keys. If the source has none, a word is
+// made up from the unlocked keys, so the lesson never gets stuck.
+func (g *Generator) Next() (string, error) {
+	allowed := make(map[rune]struct{})
+	keys := g.AllowedKeys()
+	for _, key := range keys {
+		for _, r := range key {
+			allowed[r] = struct{}{}
+		}
+	}
+
+	for i := 0; i < maxAttempts; i++ {
+		word, err := g.source.Next()
+		if err != nil {
+			return "", err
+		}
+		if onlyAllowed(word, allowed) {
+			return word, nil
+		}
+	}
+	return g.makeUp(keys), nil
+}
+
+// makeUp() function returns a random combination of the keys,
+// always including the most recently unlocked one.
+func (g *Generator) makeUp(keys []string) string {
+	length := 3 + g.rng.Intn(4)
+	letters := make([]string, length)
+	for i := range letters {
+		letters[i] = keys[g.rng.Intn(len(keys))]
+	}
+	letters[g.rng.Intn(length)] = keys[len(keys)-1]
+	return strings.Join(letters, "")
+}
+
+// onlyAllowed() function reports whether the word consists of allowed runes.
+func onlyAllowed(word string, allowed map[rune]struct{}) bool {
+	for _, r := range strings.ToLower(word) {
+		if _, ok := allowed[r]; !ok {
+			return false
+		}
+	}
+	return word != ""
+}
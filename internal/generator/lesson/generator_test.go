@@ -0,0 +1,129 @@
+package lesson
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/abilun/keybon/internal/generator"
+	"github.com/abilun/keybon/internal/typing"
+)
+
+// words is a generator cycling through its words.
+type words struct {
+	list []string
+	i    int
+}
+
+func (w *words) Next() (string, error) {
+	word := w.list[w.i%len(w.list)]
+	w.i++
+	return word, nil
+}
+
+// masteredStats() function returns statistics of the keys typed
+// often enough, accurately and at 60 WPM, that is 200ms per key.
+func masteredStats(keys ...string) *typing.KeyStats {
+	stats := typing.NewKeyStats()
+	for _, key := range keys {
+		stats.Chars[key] = &typing.KeyStat{
+			Count:   minSamples,
+			Latency: minSamples * 200 * time.Millisecond,
+			Timed:   minSamples,
+		}
+	}
+	return stats
+}
+
+func TestAllowedKeys(t *testing.T) {
+	initial := DefaultOrder[:defaultInitial]
+	tests := []struct {
+		name   string
+		modify func(stats *typing.KeyStats)
+		want   []string
+	}{
+		{"mastered", func(*typing.KeyStats) {}, DefaultOrder[:defaultInitial+1]},
+		{"next also mastered", func(stats *typing.KeyStats) {
+			stats.Chars[DefaultOrder[defaultInitial]] = &typing.KeyStat{Count: minSamples, Latency: time.Second, Timed: 5}
+		}, DefaultOrder[:defaultInitial+2]},
+		{"too few samples", func(stats *typing.KeyStats) {
+			stats.Chars["f"].Count = minSamples - 1
+		}, initial},
+		{"missing key", func(stats *typing.KeyStats) {
+			delete(stats.Chars, "a")
+		}, initial},
+		{"inaccurate", func(stats *typing.KeyStats) {
+			stats.Chars["j"].Errors = 2
+		}, initial},
+		// 35 WPM allows about 343ms per key
+		{"slow", func(stats *typing.KeyStats) {
+			stats.Chars["d"].Latency = minSamples * 400 * time.Millisecond
+		}, initial},
+	}
+	for _, tt := range tests {
+		stats := masteredStats(initial...)
+		tt.modify(stats)
+		if got := New(&words{list: []string{"x"}}, stats).AllowedKeys(); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: allowed %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestAllowedKeysUnlockAsStatsChange(t *testing.T) {
+	stats := typing.NewKeyStats()
+	var g generator.Generator = New(&words{list: []string{"x"}}, stats)
+	r, ok := g.(generator.KeyRestricter)
+	if !ok {
+		t.Fatal("lesson is not a key restricter")
+	}
+	if got := len(r.AllowedKeys()); got != defaultInitial {
+		t.Fatalf("%d keys allowed without statistics, want %d", got, defaultInitial)
+	}
+
+	*stats = *masteredStats(DefaultOrder[:defaultInitial]...)
+	if got, next := r.AllowedKeys(), DefaultOrder[defaultInitial]; got[len(got)-1] != next {
+		t.Errorf("allowed %q after mastering the initial keys, want %q unlocked", got, next)
+	}
+}
+
+func TestSetTargets(t *testing.T) {
+	g := New(&words{list: []string{"x"}}, masteredStats(DefaultOrder[:defaultInitial]...))
+	// 200ms per key is too slow for 80 WPM
+	if err := g.SetTargets(80, 95); err != nil {
+		t.Fatal(err)
+	}
+	if got := len(g.AllowedKeys()); got != defaultInitial {
+		t.Errorf("%d keys allowed, want %d", got, defaultInitial)
+	}
+
+	for _, targets := range [][2]float64{{0, 95}, {35, 0}, {35, 101}} {
+		if err := g.SetTargets(targets[0], targets[1]); err == nil {
+			t.Errorf("SetTargets(%v, %v) accepted", targets[0], targets[1])
+		}
+	}
+}
+
+func TestNext(t *testing.T) {
+	allowed := strings.Join(DefaultOrder[:defaultInitial], "")
+	tests := []struct {
+		name  string
+		words []string
+	}{
+		{"source words", []string{"the", "Flask", "quick", "salad", "jigs"}},
+		{"made up", []string{"the", "quick", "brown", "fox"}},
+	}
+	for _, tt := range tests {
+		g := New(&words{list: tt.words}, typing.NewKeyStats())
+		g.Seed(1)
+		for i := 0; i < 20; i++ {
+			word, err := g.Next()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if word == "" || strings.Trim(strings.ToLower(word), allowed) != "" {
+				t.Fatalf("%s: got %q, want only %q", tt.name, word, allowed)
+			}
+		}
+	}
+}
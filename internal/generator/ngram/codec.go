@@ -0,0 +1,120 @@
+package ngram
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// Codec is how a model is encoded in a file.
+type Codec string
+
+const (
+	// CodecBinary is the versioned binary format with
+	// a Zstandard compressed body, see FormatVersion
+	CodecBinary Codec = "binary"
+	// CodecZstd is Zstandard compressed JSON
+	CodecZstd Codec = "zstd"
+	// CodecGzip is gzip compressed JSON
+	CodecGzip Codec = "gzip"
+	// CodecJSON is uncompressed JSON
+	CodecJSON Codec = "json"
+)
+
+var (
+	zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+	gzipMagic = []byte{0x1f, 0x8b}
+)
+
+// Codecs() function returns the codecs models can be saved with.
+func Codecs() []Codec {
+	return []Codec{CodecBinary, CodecZstd, CodecGzip, CodecJSON}
+}
+
+// DetectCodec() function returns the codec of a model file
+// from its first bytes.
+func DetectCodec(data []byte) (Codec, error) {
+	switch {
+	case IsModelFile(data):
+		return CodecBinary, nil
+	case bytes.HasPrefix(data, zstdMagic):
+		return CodecZstd, nil
+	case bytes.HasPrefix(data, gzipMagic):
+		return CodecGzip, nil
+	case bytes.HasPrefix(bytes.TrimLeft(data, " \t\r\n"), []byte("{")):
+		return CodecJSON, nil
+	default:
+		return "", fmt.Errorf("%w: unknown format", ErrCorruptModel)
+	}
+}
+
+// Save() function writes the model to a writer
+// in the binary format at the default level.
+func (m *Model) Save(w io.Writer) error {
+	return m.SaveAs(w, CodecBinary, 0)
+}
+
+// SaveAs() function writes the model to a writer with the codec,
+// compressing at the given level, or the default one when 0.
+// The timestamps of the model are updated once the codec
+// and level are checked.
+func (m *Model) SaveAs(w io.Writer, codec Codec, level int) error {
+	if err := codec.CheckLevel(level); err != nil {
+		return err
+	}
+	now := time.Now().UTC().Truncate(time.Second)
+	if m.Created.IsZero() {
+		m.Created = now
+	}
+	m.Updated = now
+
+	switch codec {
+	case CodecBinary, "":
+		return m.saveFile(w, level)
+	case CodecZstd:
+		return m.CompressZstd(w, level)
+	case CodecGzip:
+		return m.CompressGzip(w, level)
+	case CodecJSON:
+		return m.EncodeJSON(w)
+	default:
+		return fmt.Errorf("unknown codec %q", codec)
+	}
+}
+
+// CheckLevel() function returns an error if the codec is unknown
+// or has no compression level of the given value.
+func (c Codec) CheckLevel(level int) error {
+	maxLevel := 0
+	switch c {
+	case CodecBinary, CodecZstd, "":
+		maxLevel = 22
+	case CodecGzip:
+		maxLevel = 9
+	case CodecJSON:
+	default:
+		return fmt.Errorf("unknown codec %q", c)
+	}
+	if level != 0 && (level < 1 || level > maxLevel) {
+		if maxLevel == 0 {
+			return fmt.Errorf("codec %q has no compression levels", c)
+		}
+		return fmt.Errorf("invalid %s compression level %d: expected 1 to %d", c, level, maxLevel)
+	}
+	return nil
+}
+
+// newZstdWriter() function creates a Zstandard encoder at the level,
+// from 1 to 22, or the default level when 0.
+func newZstdWriter(w io.Writer, level int) (*zstd.Encoder, error) {
+	if err := CodecZstd.CheckLevel(level); err != nil {
+		return nil, err
+	}
+	if level == 0 {
+		return zstd.NewWriter(w)
+	}
+	return zstd.NewWriter(w, zstd.WithEncoderLevel(zstd.EncoderLevelFromZstd(level)))
+}
@@ -0,0 +1,379 @@
+package ngram
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"sort"
+	"strings"
+
+	"github.com/abilun/keybon/internal/scanner"
+)
+
+// TokenID identifies a word of a Vocab.
+type TokenID uint32
+
+// MaxCompactOrder is the highest order of a Compact model:
+// histories are stored in fixed-size keys of this many tokens.
+const MaxCompactOrder = 8
+
+// historyKey is a history of up to MaxCompactOrder tokens,
+// padded with zeros. Histories of different lengths are
+// kept in different tables, so the padding is unambiguous.
+type historyKey [MaxCompactOrder]TokenID
+
+func makeKey(history []TokenID) historyKey {
+	var key historyKey
+	copy(key[:], history)
+	return key
+}
+
+// Vocab interns words as token IDs.
+type Vocab struct {
+	ids   map[string]TokenID
+	words []string
+}
+
+// NewVocab() function creates an empty vocabulary.
+func NewVocab() *Vocab {
+	return &Vocab{ids: make(map[string]TokenID)}
+}
+
+// Intern() function returns the ID of the word, adding it if it is new.
+func (v *Vocab) Intern(word string) TokenID {
+	if id, ok := v.ids[word]; ok {
+		return id
+	}
+	id := TokenID(len(v.words))
+	v.ids[word] = id
+	v.words = append(v.words, word)
+	return id
+}
+
+// ID() function returns the ID of the word, if it is in the vocabulary.
+func (v *Vocab) ID(word string) (TokenID, bool) {
+	id, ok := v.ids[word]
+	return id, ok
+}
+
+// Word() function returns the word with the given ID.
+func (v *Vocab) Word(id TokenID) string {
+	return v.words[id]
+}
+
+// Len() function returns the number of words in the vocabulary.
+func (v *Vocab) Len() int {
+	return len(v.words)
+}
+
+// transitions are the continuations of the histories of one length.
+// The continuations of the i-th history are next[offsets[i]:offsets[i+1]],
+// sorted by word, and cumulative holds their running count totals,
+// so a weighted choice is a binary search.
+type transitions struct {
+	index      map[historyKey]uint32
+	offsets    []uint32
+	next       []TokenID
+	cumulative []uint64
+}
+
+// lookup() function returns the continuations of the history.
+func (t *transitions) lookup(history []TokenID) ([]TokenID, []uint64) {
+	i, ok := t.index[makeKey(history)]
+	if !ok {
+		return nil, nil
+	}
+	start, end := t.offsets[i], t.offsets[i+1]
+	return t.next[start:end], t.cumulative[start:end]
+}
+
+// Compact is a read-only Model for generating text. Words are interned
+// as token IDs, histories are fixed-size keys and the continuations of
+// each history are stored in flat arrays with cumulative counts, making
+// it a fraction of the size of the Model it is built from.
+type Compact struct {
+	Order int
+	Mode  scanner.Mode
+	Vocab *Vocab
+
+	// levels[k-1] holds the histories of k tokens,
+	// levels[Order-1] the full ones and the others the back-off
+	levels []transitions
+	// starts are the start states with their cumulative counts
+	starts           []historyKey
+	startsCumulative []uint64
+}
+
+// Compact() function builds the compact form of the model.
+func (m *Model) Compact() (*Compact, error) {
+	if err := checkCompact(m.Order, m.LevelOrWord()); err != nil {
+		return nil, err
+	}
+
+	c := newCompact(m.Order, m.Mode)
+	c.addTransitions(m.Data)
+	c.addTransitions(m.Backoff)
+	if err := c.addStarts(m); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// checkCompact() function returns an error for models that
+// cannot be compacted: character models, which the pseudo package
+// generates from, and orders above MaxCompactOrder.
+func checkCompact(order int, level Level) error {
+	if level == LevelChar {
+		return fmt.Errorf("%w: character level models cannot be used to generate words", ErrLevelMismatch)
+	}
+	if order < 1 || order > MaxCompactOrder {
+		return fmt.Errorf("invalid order %d: compact models support orders from 1 to %d",
+			order, MaxCompactOrder)
+	}
+	return nil
+}
+
+// newCompact() function creates an empty compact model.
+func newCompact(order int, mode scanner.Mode) *Compact {
+	c := &Compact{
+		Order:  order,
+		Mode:   mode,
+		Vocab:  NewVocab(),
+		levels: make([]transitions, order),
+	}
+	for k := range c.levels {
+		c.levels[k].index = make(map[historyKey]uint32)
+		c.levels[k].offsets = []uint32{0}
+	}
+	return c
+}
+
+// addTransitions() function adds the histories and continuations
+// to the levels of their lengths, in sorted order of the histories.
+func (c *Compact) addTransitions(data map[string]map[string]int) {
+	for _, key := range sortedHistories(data) {
+		history := c.intern(strings.Split(key, " "))
+		if len(history) > c.Order {
+			continue
+		}
+
+		level := &c.levels[len(history)-1]
+		level.index[makeKey(history)] = uint32(len(level.offsets) - 1)
+
+		total := uint64(0)
+		for _, word := range sortedKeys(data[key]) {
+			total += uint64(data[key][word])
+			level.next = append(level.next, c.Vocab.Intern(word))
+			level.cumulative = append(level.cumulative, total)
+		}
+		level.offsets = append(level.offsets, uint32(len(level.next)))
+	}
+}
+
+// addStarts() function adds the start states of the model: its start
+// counts or, without those, all full histories weighted by their totals.
+func (c *Compact) addStarts(m *Model) error {
+	candidates := make(map[string]int)
+	for key, count := range m.Starts {
+		if len(m.Data[key]) > 0 {
+			candidates[key] = count
+		}
+	}
+	if len(candidates) == 0 {
+		for key, nexts := range m.Data {
+			for _, count := range nexts {
+				candidates[key] += count
+			}
+		}
+	}
+
+	total := uint64(0)
+	for _, key := range sortedKeys(candidates) {
+		history := c.intern(strings.Split(key, " "))
+		if len(history) != c.Order {
+			return fmt.Errorf("invalid start state %q for order %d", key, c.Order)
+		}
+		total += uint64(candidates[key])
+		c.starts = append(c.starts, makeKey(history))
+		c.startsCumulative = append(c.startsCumulative, total)
+	}
+	return nil
+}
+
+// LoadCompact() function reads a saved model straight into its compact
+// form. Models in the binary format are decoded without building the
+// Model, so they take a fraction of its memory while loading too;
+// models saved with the other codecs are loaded and compacted.
+func LoadCompact(r io.Reader) (*Compact, error) {
+	br := bufio.NewReader(r)
+	magic, _ := br.Peek(len(fileMagic))
+	if !IsModelFile(magic) {
+		var m Model
+		if err := m.Load(br); err != nil {
+			return nil, err
+		}
+		return m.Compact()
+	}
+
+	header, body, err := openFile(br)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+	if err := checkCompact(header.Order, header.Level); err != nil {
+		return nil, err
+	}
+
+	c := newCompact(header.Order, header.Mode)
+	v := &compactBody{c: c}
+	if err := decodeBody(body.r, header, v); err != nil {
+		return nil, fmt.Errorf("%w: body: %v", ErrCorruptModel, err)
+	}
+	v.finish()
+	return c, nil
+}
+
+// compactBody decodes a body into a Compact. The body stores the
+// histories and continuations in the order Compact() adds them,
+// so the model is the same as one compacted after loading.
+type compactBody struct {
+	c *Compact
+	// histories are the full-order histories, in order,
+	// for the start states of models without start counts
+	histories []historyKey
+	// starts are the start states with continuations, with their counts
+	starts      []historyKey
+	startCounts []int
+}
+
+func (b *compactBody) vocabulary(words []string) {
+	for _, word := range words {
+		b.c.Vocab.Intern(word)
+	}
+}
+
+func (b *compactBody) transitions(history, next []TokenID, counts []int) {
+	level := &b.c.levels[len(history)-1]
+	key := makeKey(history)
+	level.index[key] = uint32(len(level.offsets) - 1)
+
+	total := uint64(0)
+	for i, id := range next {
+		total += uint64(counts[i])
+		level.next = append(level.next, id)
+		level.cumulative = append(level.cumulative, total)
+	}
+	level.offsets = append(level.offsets, uint32(len(level.next)))
+
+	if len(history) == b.c.Order {
+		b.histories = append(b.histories, key)
+	}
+}
+
+func (b *compactBody) start(history []TokenID, count int) {
+	key := makeKey(history)
+	if next, _ := b.c.levels[b.c.Order-1].lookup(history); len(next) > 0 {
+		b.starts = append(b.starts, key)
+		b.startCounts = append(b.startCounts, count)
+	}
+}
+
+// finish() function adds the start states, falling back
+// to all full histories weighted by their totals like addStarts.
+func (b *compactBody) finish() {
+	c := b.c
+	total := uint64(0)
+	if len(b.starts) > 0 {
+		for i, key := range b.starts {
+			total += uint64(b.startCounts[i])
+			c.starts = append(c.starts, key)
+			c.startsCumulative = append(c.startsCumulative, total)
+		}
+		return
+	}
+
+	level := &c.levels[c.Order-1]
+	for i, key := range b.histories {
+		start, end := level.offsets[i], level.offsets[i+1]
+		if start == end {
+			continue
+		}
+		total += level.cumulative[end-1]
+		c.starts = append(c.starts, key)
+		c.startsCumulative = append(c.startsCumulative, total)
+	}
+}
+
+func (c *Compact) intern(words []string) []TokenID {
+	ids := make([]TokenID, len(words))
+	for i, word := range words {
+		ids[i] = c.Vocab.Intern(word)
+	}
+	return ids
+}
+
+// IsEmpty() function returns true if the model has no full-order histories.
+func (c *Compact) IsEmpty() bool {
+	return len(c.levels) == 0 || len(c.levels[c.Order-1].index) == 0
+}
+
+// Continuations() function returns the continuations of the history with
+// their cumulative counts, backing off to its shorter suffixes when the
+// longer ones were never seen. It returns nil on a dead end.
+func (c *Compact) Continuations(history []TokenID) ([]TokenID, []uint64) {
+	for k := min(len(history), c.Order); k > 0; k-- {
+		next, cumulative := c.levels[k-1].lookup(history[len(history)-k:])
+		if len(next) > 0 {
+			return next, cumulative
+		}
+	}
+	return nil, nil
+}
+
+// StartState() function returns a full-order history to (re)start
+// generation from, chosen proportionally to how often texts began with it.
+func (c *Compact) StartState(rng *rand.Rand) ([]TokenID, error) {
+	if len(c.starts) == 0 {
+		return nil, errors.New("model is empty")
+	}
+	key := c.starts[cumulativeIndex(rng, c.startsCumulative)]
+	history := make([]TokenID, c.Order)
+	copy(history, key[:c.Order])
+	return history, nil
+}
+
+// Counts() function returns the continuations as a map from words
+// to counts, for the choice functions.
+func (c *Compact) Counts(next []TokenID, cumulative []uint64) map[string]int {
+	counts := make(map[string]int, len(next))
+	prev := uint64(0)
+	for i, id := range next {
+		counts[c.Vocab.Word(id)] = int(cumulative[i] - prev)
+		prev = cumulative[i]
+	}
+	return counts
+}
+
+// cumulativeIndex() function returns a random index, with the
+// probability proportional to the count the cumulative total
+// grows by at the index. The choices are the same as WeightedChoice
+// makes on the counts in the same order.
+func cumulativeIndex(rng *rand.Rand, cumulative []uint64) int {
+	r := rng.Float64() * float64(cumulative[len(cumulative)-1])
+	i := sort.Search(len(cumulative), func(i int) bool {
+		return float64(cumulative[i]) > r
+	})
+	return min(i, len(cumulative)-1)
+}
+
+// sortedHistories() function returns the histories in alphabetical order.
+func sortedHistories(data map[string]map[string]int) []string {
+	keys := make([]string, 0, len(data))
+	for key := range data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,173 @@
+package ngram
+
+import (
+	"bytes"
+	"math/rand"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testText = `the quick brown fox jumps over the lazy dog.
+the quick red fox runs past the lazy cat.
+a lazy dog sleeps while the quick fox jumps again.`
+
+func testModel(t *testing.T, order int) *Model {
+	t.Helper()
+	m := NewModel(order)
+	if err := m.Fill(strings.NewReader(testText)); err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+// generate() function returns the first n words of a seeded generator.
+func generate(t *testing.T, c *Compact, n int) []string {
+	t.Helper()
+	g := NewFromCompact(c)
+	g.Seed(42)
+	if err := g.Start(); err != nil {
+		t.Fatal(err)
+	}
+	words := make([]string, n)
+	for i := range words {
+		word, err := g.Next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		words[i] = word
+	}
+	return words
+}
+
+func TestCumulativeIndexMatchesWeightedChoice(t *testing.T) {
+	counts := map[string]int{"a": 1, "b": 5, "c": 2, "d": 10}
+	keys := sortedKeys(counts)
+	cumulative := make([]uint64, len(keys))
+	total := uint64(0)
+	for i, k := range keys {
+		total += uint64(counts[k])
+		cumulative[i] = total
+	}
+
+	weighted, indexed := rand.New(rand.NewSource(7)), rand.New(rand.NewSource(7))
+	for i := 0; i < 1000; i++ {
+		want := WeightedChoice(weighted, counts)
+		if got := keys[cumulativeIndex(indexed, cumulative)]; got != want {
+			t.Fatalf("choice %d: got %q, want %q", i, got, want)
+		}
+	}
+}
+
+func TestCumulativeIndexSkipsZeroCounts(t *testing.T) {
+	// The second entry adds nothing to the total, so it is never chosen
+	cumulative := []uint64{3, 3, 5}
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 1000; i++ {
+		if got := cumulativeIndex(rng, cumulative); got == 1 {
+			t.Fatal("chose an entry with a zero count")
+		}
+	}
+}
+
+func TestContinuationsBackOff(t *testing.T) {
+	m := testModel(t, 2)
+	c, err := m.Compact()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ids := func(words ...string) []TokenID {
+		history := make([]TokenID, len(words))
+		for i, word := range words {
+			id, ok := c.Vocab.ID(word)
+			if !ok {
+				t.Fatalf("%q is not in the vocabulary", word)
+			}
+			history[i] = id
+		}
+		return history
+	}
+	counts := func(history []TokenID) map[string]int {
+		next, cumulative := c.Continuations(history)
+		if next == nil {
+			return nil
+		}
+		return c.Counts(next, cumulative)
+	}
+
+	tests := []struct {
+		history []string
+		want    map[string]int
+	}{
+		{[]string{"the", "quick"}, m.Data["the quick"]},
+		// Never seen together, backs off to "quick"
+		{[]string{"dog", "quick"}, m.Backoff["quick"]},
+		{[]string{"lazy"}, m.Backoff["lazy"]},
+	}
+	for _, tt := range tests {
+		if got := counts(ids(tt.history...)); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Continuations(%q) = %v, want %v", tt.history, got, tt.want)
+		}
+	}
+
+	// "again" ends the text, so nothing follows it
+	if next, _ := c.Continuations(ids("jumps", "again")); next != nil {
+		t.Errorf("Continuations of a dead end = %v, want nil", next)
+	}
+}
+
+func TestLoadCompactMatchesCompact(t *testing.T) {
+	for order := 1; order <= 3; order++ {
+		m := testModel(t, order)
+		want, err := m.Compact()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, codec := range []Codec{CodecBinary, CodecJSON} {
+			var buf bytes.Buffer
+			if err := m.SaveAs(&buf, codec, 0); err != nil {
+				t.Fatal(err)
+			}
+			got, err := LoadCompact(&buf)
+			if err != nil {
+				t.Fatalf("order %d, %s: %v", order, codec, err)
+			}
+			if !reflect.DeepEqual(generate(t, got, 50), generate(t, want, 50)) {
+				t.Errorf("order %d, %s: loaded compact model generates different text", order, codec)
+			}
+		}
+	}
+}
+
+func TestLoadCompactWithoutStarts(t *testing.T) {
+	m := testModel(t, 2)
+	m.Starts = make(map[string]int)
+	want, err := m.Compact()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := m.Save(&buf); err != nil {
+		t.Fatal(err)
+	}
+	got, err := LoadCompact(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(generate(t, got, 50), generate(t, want, 50)) {
+		t.Error("loaded compact model generates different text")
+	}
+}
+
+func TestLoadCompactRejectsHighOrders(t *testing.T) {
+	var buf bytes.Buffer
+	if err := testModel(t, MaxCompactOrder+1).Save(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadCompact(&buf); err == nil {
+		t.Error("loaded a model of order above MaxCompactOrder")
+	}
+}
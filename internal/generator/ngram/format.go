@@ -0,0 +1,515 @@
+package ngram
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/abilun/keybon/internal/scanner"
+	"github.com/klauspost/compress/zstd"
+)
+
+// A model file starts with the magic bytes and the format version,
+// followed by the length-prefixed JSON Header, the length-prefixed
+// zstd compressed body and the SHA-256 checksum of everything before it.
+//
+// The body holds the vocabulary, sorted, as length-prefixed words,
+// then the Data and Backoff histories, each as the number of histories
+// followed by the token IDs of every history and its continuations
+// with their counts, then the start states with their counts.
+// All numbers in the body are unsigned varints.
+var fileMagic = []byte("KBNGRAM\x00")
+
+// FormatVersion is the version of the model files written with CodecBinary.
+const FormatVersion = 1
+
+const (
+	// maxHeaderSize bounds the header of model files.
+	maxHeaderSize = 64 << 20
+	// compressionZstd is the compression of the body.
+	compressionZstd = "zstd"
+)
+
+var (
+	// ErrCorruptModel is returned by Load when a model file fails
+	// its checksum or is malformed.
+	ErrCorruptModel = errors.New("corrupt model file")
+	// ErrUnsupportedVersion is returned by Load for model files
+	// written by a newer version of the format.
+	ErrUnsupportedVersion = errors.New("unsupported model file version")
+)
+
+// Header describes a model file. It can be read without the rest
+// of the file by ReadHeader.
+type Header struct {
+	Version       int                    `json:"-"`
+	Order         int                    `json:"order"`
+	Level         Level                  `json:"level,omitempty"`
+	Mode          scanner.Mode           `json:"mode,omitempty"`
+	Normalization *scanner.Normalization `json:"normalization,omitempty"`
+	VocabSize     int                    `json:"vocab_size"`
+	Histories     int                    `json:"histories"`
+	// Hashes are the SHA-256 sums of the texts the model was trained on
+	Hashes      []string  `json:"hashes,omitempty"`
+	Created     time.Time `json:"created"`
+	Updated     time.Time `json:"updated"`
+	Compression string    `json:"compression"`
+}
+
+// IsModelFile() function reports whether the data starts like a model file.
+func IsModelFile(data []byte) bool {
+	return bytes.HasPrefix(data, fileMagic)
+}
+
+// saveFile() function writes the model to a writer in the versioned
+// binary format, compressing the body at the Zstandard level.
+func (m *Model) saveFile(w io.Writer, level int) error {
+	vocab := m.vocabulary()
+	var body bytes.Buffer
+	encoder, err := newZstdWriter(&body, level)
+	if err != nil {
+		return err
+	}
+	bw := bufio.NewWriter(encoder)
+	m.encodeBody(bw, vocab)
+	if err := bw.Flush(); err != nil {
+		return err
+	}
+	if err := encoder.Close(); err != nil {
+		return err
+	}
+
+	header, err := json.Marshal(m.header(len(vocab)))
+	if err != nil {
+		return err
+	}
+
+	checksum := sha256.New()
+	out := io.MultiWriter(w, checksum)
+	if _, err := out.Write(fileMagic); err != nil {
+		return err
+	}
+	if err := binary.Write(out, binary.BigEndian, uint16(FormatVersion)); err != nil {
+		return err
+	}
+	if err := writeBlock(out, header); err != nil {
+		return err
+	}
+	if err := writeBlock(out, body.Bytes()); err != nil {
+		return err
+	}
+	_, err = w.Write(checksum.Sum(nil))
+	return err
+}
+
+// header() function returns the header describing the model.
+func (m *Model) header(vocabSize int) Header {
+	hashes := make([]string, 0, len(m.Hashes))
+	for sum := range m.Hashes {
+		hashes = append(hashes, sum)
+	}
+	sort.Strings(hashes)
+
+	return Header{
+		Version:       FormatVersion,
+		Order:         m.Order,
+		Level:         m.Level,
+		Mode:          m.Mode,
+		Normalization: m.Normalization,
+		VocabSize:     vocabSize,
+		Histories:     len(m.Data),
+		Hashes:        hashes,
+		Created:       m.Created,
+		Updated:       m.Updated,
+		Compression:   compressionZstd,
+	}
+}
+
+// vocabulary() function returns the sorted words of the model.
+func (m *Model) vocabulary() []string {
+	words := make(map[string]struct{})
+	add := func(key string) {
+		for _, word := range strings.Split(key, " ") {
+			words[word] = struct{}{}
+		}
+	}
+	for _, data := range []map[string]map[string]int{m.Data, m.Backoff} {
+		for key, nexts := range data {
+			add(key)
+			for word := range nexts {
+				words[word] = struct{}{}
+			}
+		}
+	}
+	for key := range m.Starts {
+		add(key)
+	}
+
+	vocab := make([]string, 0, len(words))
+	for word := range words {
+		vocab = append(vocab, word)
+	}
+	sort.Strings(vocab)
+	return vocab
+}
+
+// encodeBody() function writes the vocabulary and the counts of the model.
+// Errors are left to the caller's Flush, as bufio.Writer keeps them.
+func (m *Model) encodeBody(w *bufio.Writer, vocab []string) {
+	ids := make(map[string]uint64, len(vocab))
+	buf := make([]byte, binary.MaxVarintLen64)
+	putUvarint := func(x uint64) {
+		w.Write(buf[:binary.PutUvarint(buf, x)])
+	}
+	putHistory := func(key string) {
+		words := strings.Split(key, " ")
+		putUvarint(uint64(len(words)))
+		for _, word := range words {
+			putUvarint(ids[word])
+		}
+	}
+
+	putUvarint(uint64(len(vocab)))
+	for i, word := range vocab {
+		ids[word] = uint64(i)
+		putUvarint(uint64(len(word)))
+		w.WriteString(word)
+	}
+
+	for _, data := range []map[string]map[string]int{m.Data, m.Backoff} {
+		putUvarint(uint64(len(data)))
+		for _, key := range sortedHistories(data) {
+			putHistory(key)
+			putUvarint(uint64(len(data[key])))
+			for _, word := range sortedKeys(data[key]) {
+				putUvarint(ids[word])
+				putUvarint(uint64(data[key][word]))
+			}
+		}
+	}
+
+	putUvarint(uint64(len(m.Starts)))
+	for _, key := range sortedKeys(m.Starts) {
+		putHistory(key)
+		putUvarint(uint64(m.Starts[key]))
+	}
+}
+
+// ReadHeader() function reads the header of a model file
+// without reading, or verifying, the rest of it.
+func ReadHeader(r io.Reader) (*Header, error) {
+	prefix := make([]byte, len(fileMagic)+2)
+	if _, err := io.ReadFull(r, prefix); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrCorruptModel, err)
+	}
+	if !IsModelFile(prefix) {
+		return nil, fmt.Errorf("%w: not a model file", ErrCorruptModel)
+	}
+	version := int(binary.BigEndian.Uint16(prefix[len(fileMagic):]))
+	if version < 1 || version > FormatVersion {
+		return nil, fmt.Errorf("%w: version %d, expected at most %d",
+			ErrUnsupportedVersion, version, FormatVersion)
+	}
+
+	block, err := readBlock(r, maxHeaderSize)
+	if err != nil {
+		return nil, fmt.Errorf("%w: header: %v", ErrCorruptModel, err)
+	}
+	var header Header
+	if err := json.Unmarshal(block, &header); err != nil {
+		return nil, fmt.Errorf("%w: header: %v", ErrCorruptModel, err)
+	}
+	header.Version = version
+	return &header, nil
+}
+
+// loadFile() function reads a model file, verifying its checksum
+// and checking the body against the header.
+func loadFile(r io.Reader) (*Model, error) {
+	header, body, err := openFile(r)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	m := NewModel(header.Order)
+	v := &modelBody{m: m}
+	if err := decodeBody(body.r, header, v); err != nil {
+		return nil, fmt.Errorf("%w: body: %v", ErrCorruptModel, err)
+	}
+
+	m.Level = header.Level
+	m.Mode = header.Mode
+	m.Normalization = header.Normalization
+	m.Created, m.Updated = header.Created, header.Updated
+	for _, sum := range header.Hashes {
+		m.Hashes[sum] = struct{}{}
+	}
+	return m, nil
+}
+
+// fileBody is the decompressed body of a model file.
+type fileBody struct {
+	r       *bufio.Reader
+	decoder *zstd.Decoder
+}
+
+func (b *fileBody) Close() {
+	b.decoder.Close()
+}
+
+// openFile() function reads the header of a model file and checks it,
+// then reads the body and verifies the checksum before decompressing it.
+func openFile(r io.Reader) (*Header, *fileBody, error) {
+	checksum := sha256.New()
+	tee := io.TeeReader(r, checksum)
+
+	header, err := ReadHeader(tee)
+	if err != nil {
+		return nil, nil, err
+	}
+	if header.Compression != compressionZstd {
+		return nil, nil, fmt.Errorf("%w: unknown compression %q", ErrCorruptModel, header.Compression)
+	}
+	if header.Order < 1 {
+		return nil, nil, fmt.Errorf("%w: invalid order %d", ErrCorruptModel, header.Order)
+	}
+	if err := header.Level.check(); err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", ErrCorruptModel, err)
+	}
+	if _, err := scanner.NewTokenizer(header.Mode); err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", ErrCorruptModel, err)
+	}
+	if header.Normalization != nil {
+		if _, err := header.Normalization.Pipeline(); err != nil {
+			return nil, nil, fmt.Errorf("%w: %v", ErrCorruptModel, err)
+		}
+	}
+
+	var size uint64
+	if err := binary.Read(tee, binary.BigEndian, &size); err != nil {
+		return nil, nil, fmt.Errorf("%w: body: %v", ErrCorruptModel, err)
+	}
+	// The body is read whole before decoding, so the checksum
+	// is verified before any of it is trusted
+	body, err := io.ReadAll(io.LimitReader(tee, int64(min(size, math.MaxInt64))))
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: body: %v", ErrCorruptModel, err)
+	}
+	if uint64(len(body)) != size {
+		return nil, nil, fmt.Errorf("%w: body: %v", ErrCorruptModel, io.ErrUnexpectedEOF)
+	}
+
+	sum := make([]byte, sha256.Size)
+	if _, err := io.ReadFull(r, sum); err != nil {
+		return nil, nil, fmt.Errorf("%w: checksum: %v", ErrCorruptModel, err)
+	}
+	if !bytes.Equal(sum, checksum.Sum(nil)) {
+		return nil, nil, fmt.Errorf("%w: checksum mismatch", ErrCorruptModel)
+	}
+
+	decoder, err := zstd.NewReader(bytes.NewReader(body))
+	if err != nil {
+		return nil, nil, err
+	}
+	return header, &fileBody{bufio.NewReader(decoder), decoder}, nil
+}
+
+// bodyVisitor receives the contents of a body as decodeBody reads them:
+// the vocabulary first, then the continuations of the full-order and
+// the back-off histories, in the order they were saved, then the start
+// states. Token IDs index the vocabulary.
+type bodyVisitor interface {
+	vocabulary(words []string)
+	transitions(history, next []TokenID, counts []int)
+	start(history []TokenID, count int)
+}
+
+// decodeBody() function reads the vocabulary and the counts of a model,
+// checking them against the header, and passes them to the visitor.
+func decodeBody(r *bufio.Reader, header *Header, v bodyVisitor) error {
+	uvarint := func() (int, error) {
+		x, err := binary.ReadUvarint(r)
+		if err == nil && x > 1<<31 {
+			err = fmt.Errorf("number %d out of range", x)
+		}
+		return int(x), err
+	}
+
+	vocabSize, err := uvarint()
+	if err != nil {
+		return err
+	}
+	if vocabSize != header.VocabSize {
+		return fmt.Errorf("%d words, header says %d", vocabSize, header.VocabSize)
+	}
+	vocab := make([]string, vocabSize)
+	for i := range vocab {
+		n, err := uvarint()
+		if err != nil {
+			return err
+		}
+		word := make([]byte, n)
+		if _, err := io.ReadFull(r, word); err != nil {
+			return err
+		}
+		vocab[i] = string(word)
+	}
+	v.vocabulary(vocab)
+
+	count := func() (int, error) {
+		n, err := uvarint()
+		if err == nil && n == 0 {
+			err = errors.New("zero count")
+		}
+		return n, err
+	}
+	word := func() (TokenID, error) {
+		id, err := uvarint()
+		if err != nil {
+			return 0, err
+		}
+		if id >= len(vocab) {
+			return 0, fmt.Errorf("token ID %d out of range", id)
+		}
+		return TokenID(id), nil
+	}
+	history := func(minLen, maxLen int) ([]TokenID, error) {
+		n, err := uvarint()
+		if err != nil {
+			return nil, err
+		}
+		if n < minLen || n > maxLen {
+			return nil, fmt.Errorf("history of %d words in a model of order %d", n, header.Order)
+		}
+		ids := make([]TokenID, n)
+		for i := range ids {
+			if ids[i], err = word(); err != nil {
+				return nil, err
+			}
+		}
+		return ids, nil
+	}
+
+	sections := []struct{ minLen, maxLen int }{
+		{header.Order, header.Order},
+		{1, header.Order - 1},
+	}
+	for i, section := range sections {
+		histories, err := uvarint()
+		if err != nil {
+			return err
+		}
+		if i == 0 && histories != header.Histories {
+			return fmt.Errorf("%d histories, header says %d", histories, header.Histories)
+		}
+		for j := 0; j < histories; j++ {
+			ids, err := history(section.minLen, section.maxLen)
+			if err != nil {
+				return err
+			}
+			n, err := uvarint()
+			if err != nil {
+				return err
+			}
+			next, counts := make([]TokenID, n), make([]int, n)
+			for k := range next {
+				if next[k], err = word(); err != nil {
+					return err
+				}
+				if counts[k], err = count(); err != nil {
+					return err
+				}
+			}
+			v.transitions(ids, next, counts)
+		}
+	}
+
+	starts, err := uvarint()
+	if err != nil {
+		return err
+	}
+	for i := 0; i < starts; i++ {
+		ids, err := history(header.Order, header.Order)
+		if err != nil {
+			return err
+		}
+		n, err := count()
+		if err != nil {
+			return err
+		}
+		v.start(ids, n)
+	}
+	return nil
+}
+
+// modelBody decodes a body into a Model.
+type modelBody struct {
+	m     *Model
+	vocab []string
+}
+
+func (b *modelBody) vocabulary(words []string) {
+	b.vocab = words
+}
+
+func (b *modelBody) key(ids []TokenID) string {
+	words := make([]string, len(ids))
+	for i, id := range ids {
+		words[i] = b.vocab[id]
+	}
+	return strings.Join(words, " ")
+}
+
+func (b *modelBody) transitions(history, next []TokenID, counts []int) {
+	data := b.m.Data
+	if len(history) < b.m.Order {
+		data = b.m.Backoff
+	}
+	nexts := make(map[string]int, len(next))
+	for i, id := range next {
+		nexts[b.vocab[id]] = counts[i]
+	}
+	data[b.key(history)] = nexts
+}
+
+func (b *modelBody) start(history []TokenID, count int) {
+	b.m.Starts[b.key(history)] = count
+}
+
+// writeBlock() function writes the length of the data followed by the data.
+func writeBlock(w io.Writer, data []byte) error {
+	if _, err := w.Write(lengthPrefix(len(data))); err != nil {
+		return err
+	}
+	_, err := w.Write(data)
+	return err
+}
+
+// readBlock() function reads data written by writeBlock,
+// failing if it is longer than limit.
+func readBlock(r io.Reader, limit uint64) ([]byte, error) {
+	var size uint64
+	if err := binary.Read(r, binary.BigEndian, &size); err != nil {
+		return nil, err
+	}
+	if size > limit {
+		return nil, fmt.Errorf("block of %d bytes exceeds %d", size, limit)
+	}
+	data := make([]byte, size)
+	_, err := io.ReadFull(r, data)
+	return data, err
+}
+
+func lengthPrefix(n int) []byte {
+	return binary.BigEndian.AppendUint64(nil, uint64(n))
+}
@@ -0,0 +1,114 @@
+package ngram
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/abilun/keybon/internal/scanner"
+)
+
+// fullModel() function returns a model using every field saved in model files.
+func fullModel(t *testing.T) *Model {
+	t.Helper()
+	m := NewModel(2)
+	m.Mode = scanner.ModeWords
+	m.Normalization = &scanner.Normalization{
+		Form:      scanner.FormNFC,
+		FoldCase:  true,
+		StopWords: []string{"a"},
+	}
+	if err := m.FillWithHash(strings.NewReader(testText)); err != nil {
+		t.Fatal(err)
+	}
+	if len(m.Backoff) == 0 || len(m.Starts) == 0 {
+		t.Fatal("test model has no back-off counts or start states")
+	}
+	return m
+}
+
+// saved() function returns the model saved in the binary format.
+func saved(t *testing.T, m *Model) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := m.Save(&buf); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestFileRoundTrip(t *testing.T) {
+	want := fullModel(t)
+	data := saved(t, want)
+
+	var got Model
+	if err := got.Load(bytes.NewReader(data)); err != nil {
+		t.Fatal(err)
+	}
+	if !got.Created.Equal(want.Created) || !got.Updated.Equal(want.Updated) {
+		t.Errorf("timestamps = %v, %v, want %v, %v", got.Created, got.Updated, want.Created, want.Updated)
+	}
+	got.Created, got.Updated = want.Created, want.Updated
+	if !reflect.DeepEqual(&got, want) {
+		t.Errorf("loaded model differs from the saved one:\ngot  %+v\nwant %+v", &got, want)
+	}
+
+	header, err := ReadHeader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if header.Version != FormatVersion || header.Order != want.Order || header.Level != LevelWord ||
+		header.Mode != want.Mode || len(header.Hashes) != 1 {
+		t.Errorf("unexpected header %+v", header)
+	}
+}
+
+func TestLoadRejectsCorruptBody(t *testing.T) {
+	data := saved(t, fullModel(t))
+	// The last 32 bytes are the checksum, the body is right before it
+	data[len(data)-sha256.Size-1] ^= 0xff
+
+	var m Model
+	if err := m.Load(bytes.NewReader(data)); !errors.Is(err, ErrCorruptModel) {
+		t.Errorf("Load() error = %v, want ErrCorruptModel", err)
+	}
+}
+
+func TestLoadRejectsNewerVersions(t *testing.T) {
+	data := saved(t, fullModel(t))
+	binary.BigEndian.PutUint16(data[len(fileMagic):], FormatVersion+1)
+
+	var m Model
+	if err := m.Load(bytes.NewReader(data)); !errors.Is(err, ErrUnsupportedVersion) {
+		t.Errorf("Load() error = %v, want ErrUnsupportedVersion", err)
+	}
+	if _, err := ReadHeader(bytes.NewReader(data)); !errors.Is(err, ErrUnsupportedVersion) {
+		t.Errorf("ReadHeader() error = %v, want ErrUnsupportedVersion", err)
+	}
+}
+
+func TestLoadLegacyZstdJSON(t *testing.T) {
+	want := fullModel(t)
+	// Models were saved as Zstandard compressed JSON before the binary format
+	want.Level = ""
+	var buf bytes.Buffer
+	if err := want.CompressZstd(&buf, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	var got Model
+	if err := got.Load(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if got.LevelOrWord() != LevelWord {
+		t.Errorf("level = %q, want a word model", got.Level)
+	}
+	if !reflect.DeepEqual(got.Data, want.Data) || !reflect.DeepEqual(got.Backoff, want.Backoff) ||
+		!reflect.DeepEqual(got.Starts, want.Starts) || !reflect.DeepEqual(got.Hashes, want.Hashes) {
+		t.Error("loaded legacy model differs from the saved one")
+	}
+}
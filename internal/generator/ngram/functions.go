@@ -1,32 +1,157 @@
 package ngram
 
-import "math/rand"
+import (
+	"math"
+	"math/rand"
+	"sort"
+)
+
+// ChoiceFunc chooses the next word among the continuations
+// of a history, using rng as its only source of randomness.
+type ChoiceFunc func(rng *rand.Rand, m map[string]int) string
 
 // WeightedChoice returns a random key from the map,
 // with the probability of each key being proportional to its value.
-func WeightedChoice(m map[string]int) string {
-	total := 0
-	for _, count := range m {
-		total += count
-	}
-	r := rand.Intn(total)
-	for word, count := range m {
-		if r < count {
-			return word
+func WeightedChoice(rng *rand.Rand, m map[string]int) string {
+	keys := sortedKeys(m)
+	if len(keys) == 0 {
+		return ""
+	}
+	return weightedChoiceOf(rng, m, keys)
+}
+
+// RandomChoice returns a random key from the map,
+// ignoring the counts, for the most varied text.
+func RandomChoice(rng *rand.Rand, m map[string]int) string {
+	keys := sortedKeys(m)
+	if len(keys) == 0 {
+		return ""
+	}
+	return keys[rng.Intn(len(keys))]
+}
+
+// MostLikely returns the key with the highest value,
+// breaking ties alphabetically, so the choice is deterministic.
+func MostLikely(_ *rand.Rand, m map[string]int) string {
+	keys := sortedByCount(m)
+	if len(keys) == 0 {
+		return ""
+	}
+	return keys[0]
+}
+
+// Temperature returns a choice function that rescales counts
+// by the power of 1/t before a weighted choice. Values below 1
+// make frequent words more likely, values above 1 flatten the
+// distribution. A non-positive t is the same as MostLikely.
+func Temperature(t float64) ChoiceFunc {
+	if t <= 0 {
+		return MostLikely
+	}
+	return func(rng *rand.Rand, m map[string]int) string {
+		keys := sortedByCount(m)
+		if len(keys) == 0 {
+			return ""
+		}
+		// Scaled relative to the largest count, so the weights
+		// stay within (0, 1] instead of overflowing for small t
+		weights := make([]float64, len(keys))
+		largest := math.Log(float64(m[keys[0]]))
+		for i, k := range keys {
+			weights[i] = math.Exp((math.Log(float64(m[k])) - largest) / t)
 		}
-		r -= count
+		return keys[weightedIndex(rng, weights)]
 	}
-	for word := range m {
-		return word
+}
+
+// TopK returns a choice function that makes a weighted choice
+// among the k most frequent keys only.
+func TopK(k int) ChoiceFunc {
+	if k < 1 {
+		k = 1
+	}
+	return func(rng *rand.Rand, m map[string]int) string {
+		keys := sortedByCount(m)
+		if len(keys) > k {
+			keys = keys[:k]
+		}
+		return weightedChoiceOf(rng, m, keys)
 	}
-	return ""
 }
 
-// RandomChoice returns a random key from the map.
-func RandomChoice(m map[string]int) string {
+// TopP returns a choice function that makes a weighted choice
+// among the smallest set of most frequent keys whose cumulative
+// probability reaches p (nucleus sampling).
+func TopP(p float64) ChoiceFunc {
+	return func(rng *rand.Rand, m map[string]int) string {
+		keys := sortedByCount(m)
+		total := 0
+		for _, k := range keys {
+			total += m[k]
+		}
+
+		cumulative := 0
+		for i, k := range keys {
+			cumulative += m[k]
+			if float64(cumulative) >= p*float64(total) {
+				keys = keys[:i+1]
+				break
+			}
+		}
+		return weightedChoiceOf(rng, m, keys)
+	}
+}
+
+// sortedKeys returns the keys of the map in alphabetical order,
+// so choices do not depend on the map iteration order.
+func sortedKeys(m map[string]int) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// sortedByCount returns the keys of the map ordered
+// by descending value, ties broken alphabetically.
+func sortedByCount(m map[string]int) []string {
 	keys := make([]string, 0, len(m))
 	for k := range m {
 		keys = append(keys, k)
 	}
-	return keys[rand.Intn(len(keys))]
+	sort.Slice(keys, func(i, j int) bool {
+		if m[keys[i]] != m[keys[j]] {
+			return m[keys[i]] > m[keys[j]]
+		}
+		return keys[i] < keys[j]
+	})
+	return keys
+}
+
+// weightedChoiceOf returns one of the given keys,
+// with the probability proportional to its value in the map.
+func weightedChoiceOf(rng *rand.Rand, m map[string]int, keys []string) string {
+	weights := make([]float64, len(keys))
+	for i, k := range keys {
+		weights[i] = float64(m[k])
+	}
+	return keys[weightedIndex(rng, weights)]
+}
+
+// weightedIndex returns a random index of the slice,
+// with the probability proportional to its weight.
+func weightedIndex(rng *rand.Rand, weights []float64) int {
+	total := 0.0
+	for _, w := range weights {
+		total += w
+	}
+	r := rng.Float64() * total
+	for i, w := range weights {
+		if r < w {
+			return i
+		}
+		r -= w
+	}
+	return len(weights) - 1
 }
@@ -2,66 +2,104 @@ package ngram
 
 import (
 	"errors"
+	"math/rand"
+	"time"
 
-	"strings"
+	"github.com/abilun/keybon/internal/scanner"
 )
 
+// maxBoundaries bounds the number of consecutive
+// sentence boundaries skipped while looking for a word.
+const maxBoundaries = 100
+
+// Generator generates text from the compact form of a model.
 type Generator struct {
-	*Model
-	history  []string
-	nextFunc func(map[string]int) string
+	compact  *Compact
+	history  []TokenID
+	nextFunc ChoiceFunc
+	rng      *rand.Rand
 }
 
-// TODO: what if I first call New, then Import?
-// New() function creates a new NgramGenerator with the given order.
-func New(order int) *Generator {
-	if order < 1 {
-		panic("order must be greater than 0")
-	}
-	ng := &Generator{
-		Model: &Model{
-			Order: order,
-			Data:  make(map[string]map[string]int),
-		},
-		history: make([]string, 0, order),
+// NewFromCompact() function creates a new NgramGenerator backed
+// by a compact model only, so the Model it was built from
+// does not need to be kept in memory.
+func NewFromCompact(c *Compact) *Generator {
+	return &Generator{
+		compact: c,
+		rng:     rand.New(rand.NewSource(time.Now().UnixNano())),
 	}
-	ng.NextFunc(WeightedChoice)
-
-	return ng
 }
 
-func (ng *Generator) NextFunc(nextFunc func(map[string]int) string) {
+// NextFunc() function sets the function used to choose the next word
+// among the continuations of the current history, e.g. MostLikely,
+// or a strategy returned by Temperature, TopK or TopP. The default,
+// nil, makes the same choices as WeightedChoice by a binary search
+// over the cumulative counts, without building a map per word.
+func (ng *Generator) NextFunc(nextFunc ChoiceFunc) {
 	ng.nextFunc = nextFunc
 }
 
-// Start() function starts the model and sets
-// the initial history to the first key in the model.
+// Seed() function makes the generated text reproducible:
+// the same seed, model and choice function give the same words.
+// It should be called before Start.
+func (ng *Generator) Seed(seed int64) {
+	ng.rng = rand.New(rand.NewSource(seed))
+}
+
+// Start() function sets the initial history to a frequent start state.
 func (ng *Generator) Start() error {
-	if len(ng.Model.Data) == 0 {
+	if ng.compact == nil || ng.compact.IsEmpty() {
 		return errors.New("model is empty")
 	}
-	for k := range ng.Model.Data {
-		ng.history = strings.Split(k, " ")
-		break
+	return ng.restart()
+}
+
+// restart() function resets the history to a start state of the model.
+func (ng *Generator) restart() error {
+	history, err := ng.compact.StartState(ng.rng)
+	if err != nil {
+		return err
 	}
+	ng.history = history
 	return nil
 }
 
 // Next() function returns the next word in the model based on the current history.
+// When the history has no continuation, it backs off to shorter histories
+// and, on a dead end, restarts from a frequent start state.
 func (ng *Generator) Next() (string, error) {
-	if ng.Model.IsEmpty() {
-		return "", errors.New("model is empty")
-	}
-	if ng.history == nil {
+	if ng.compact == nil || ng.history == nil {
 		return "", errors.New("generator is not started")
 	}
 
-	key := strings.Join(ng.history, " ")
-	nexts, ok := ng.Model.Data[key]
-	if !ok || len(nexts) == 0 {
-		return "", errors.New("no next words")
+	// Sentence boundaries are kept in the history, but not returned
+	for i := 0; i < maxBoundaries; i++ {
+		next, cumulative := ng.compact.Continuations(ng.history)
+		if len(next) == 0 {
+			if err := ng.restart(); err != nil {
+				return "", err
+			}
+			next, cumulative = ng.compact.Continuations(ng.history)
+		}
+		id := ng.choose(next, cumulative)
+		ng.history = append(ng.history[1:], id)
+		if word := ng.compact.Vocab.Word(id); word != scanner.SentenceBoundary {
+			return word, nil
+		}
+	}
+	return "", errors.New("no words between sentence boundaries")
+}
+
+// choose() function chooses among the continuations
+// with the choice function, if one was set.
+func (ng *Generator) choose(next []TokenID, cumulative []uint64) TokenID {
+	if ng.nextFunc == nil {
+		return next[cumulativeIndex(ng.rng, cumulative)]
+	}
+	id, ok := ng.compact.Vocab.ID(ng.nextFunc(ng.rng, ng.compact.Counts(next, cumulative)))
+	if !ok {
+		// Choice functions only return one of the words given
+		return next[0]
 	}
-	next := WeightedChoice(nexts)
-	ng.history = append(ng.history[1:], next)
-	return next, nil
+	return id
 }
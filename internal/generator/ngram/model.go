@@ -1,27 +1,95 @@
 package ngram
 
 import (
+	"bufio"
+	"bytes"
 	"crypto/sha256"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"reflect"
 	"strings"
+	"time"
 
 	"github.com/abilun/keybon/internal/scanner"
+	"github.com/klauspost/compress/gzip"
 	"github.com/klauspost/compress/zstd"
 )
 
-// TODO: check if fill, load, save consistent on order
 // TODO: revisit fields & methods that should be exported
 
+var (
+	// ErrDuplicateContent is returned by FillWithHash
+	// when the same content was already added to the model.
+	ErrDuplicateContent = errors.New("duplicate content: already processed")
+	// ErrOrderMismatch is returned when models
+	// or inputs of different orders are mixed.
+	ErrOrderMismatch = errors.New("model order mismatch")
+	// ErrLevelMismatch is returned when word and character
+	// level models are mixed or used in place of each other.
+	ErrLevelMismatch = errors.New("model level mismatch")
+)
+
+const (
+	// WordStart pads the history before the first
+	// letter of a word in character level models.
+	WordStart = "^"
+	// WordEnd is the continuation that ends a word
+	// in character level models.
+	WordEnd = "$"
+)
+
+// Level is what the tokens of a model are.
+type Level string
+
+const (
+	// LevelWord models are sequences of words, the default
+	LevelWord Level = "word"
+	// LevelChar models are sequences of letters within words,
+	// see the pseudo package
+	LevelChar Level = "char"
+)
+
+// Model stores how often each word follows a history.
+// Data holds histories of exactly Order words, Backoff holds
+// their shorter suffixes for falling back on unseen histories,
+// and Starts counts the histories each filled text or sentence
+// began with. Mode is the scanner mode the text is split with
+// and Normalization is applied to the tokens. Level tells word
+// models from character ones. Created and Updated are set by Save.
 type Model struct {
-	Order  int                       `json:"order"`
-	Data   map[string]map[string]int `json:"data"`
-	Hashes map[string]struct{}       `json:"hashes"`
+	Order         int                    `json:"order"`
+	Level         Level                  `json:"level,omitempty"`
+	Mode          scanner.Mode           `json:"mode,omitempty"`
+	Normalization *scanner.Normalization `json:"normalization,omitempty"`
+
+	Data    map[string]map[string]int `json:"data"`
+	Backoff map[string]map[string]int `json:"backoff,omitempty"`
+	Starts  map[string]int            `json:"starts,omitempty"`
+	Hashes  map[string]struct{}       `json:"hashes"`
+
+	Created time.Time `json:"created"`
+	Updated time.Time `json:"updated"`
+}
+
+// NewModel() function creates an empty model with the given order.
+func NewModel(order int) *Model {
+	if order < 1 {
+		panic("order must be greater than 0")
+	}
+	return &Model{
+		Order:   order,
+		Level:   LevelWord,
+		Data:    make(map[string]map[string]int),
+		Backoff: make(map[string]map[string]int),
+		Starts:  make(map[string]int),
+		Hashes:  make(map[string]struct{}),
+	}
 }
 
 // Add() function adds a word to the model with the given history.
+// Histories shorter than the order are stored as back-off counts.
 func (m *Model) Add(history []string, word string) error {
 	if len(history) == 0 {
 		return errors.New("history is empty")
@@ -30,62 +98,236 @@ func (m *Model) Add(history []string, word string) error {
 		return errors.New("history is longer than order")
 	}
 
+	data := m.Data
+	if len(history) < m.Order {
+		if m.Backoff == nil {
+			m.Backoff = make(map[string]map[string]int)
+		}
+		data = m.Backoff
+	}
+
 	key := strings.Join(history, " ")
-	if _, ok := m.Data[key]; !ok {
-		m.Data[key] = make(map[string]int)
+	if _, ok := data[key]; !ok {
+		data[key] = make(map[string]int)
 	}
-	m.Data[key][word]++
+	data[key][word]++
 
 	return nil
 }
 
+// Merge() function adds the counts and hashes of the other model
+// to the model. Both must have the same order, mode and normalization.
+func (m *Model) Merge(other *Model) error {
+	if m.Order != other.Order {
+		return fmt.Errorf("%w: %d and %d", ErrOrderMismatch, m.Order, other.Order)
+	}
+	if m.LevelOrWord() != other.LevelOrWord() {
+		return fmt.Errorf("%w: %s and %s", ErrLevelMismatch, m.LevelOrWord(), other.LevelOrWord())
+	}
+	if other.Mode != m.Mode && !(isLetters(m.Mode) && isLetters(other.Mode)) {
+		return fmt.Errorf("mode mismatch: %s and %s", m.Mode, other.Mode)
+	}
+	if !reflect.DeepEqual(normalizationOf(m), normalizationOf(other)) {
+		return errors.New("normalization mismatch")
+	}
+
+	if m.Data == nil {
+		m.Data = make(map[string]map[string]int)
+	}
+	if m.Backoff == nil {
+		m.Backoff = make(map[string]map[string]int)
+	}
+	if m.Starts == nil {
+		m.Starts = make(map[string]int)
+	}
+	if m.Hashes == nil {
+		m.Hashes = make(map[string]struct{})
+	}
+
+	mergeCounts(m.Data, other.Data)
+	mergeCounts(m.Backoff, other.Backoff)
+	for key, count := range other.Starts {
+		m.Starts[key] += count
+	}
+	for sum := range other.Hashes {
+		m.Hashes[sum] = struct{}{}
+	}
+	if m.Created.IsZero() || (!other.Created.IsZero() && other.Created.Before(m.Created)) {
+		m.Created = other.Created
+	}
+	return nil
+}
+
+// mergeCounts() function adds the counts of src to dst.
+func mergeCounts(dst, src map[string]map[string]int) {
+	for key, nexts := range src {
+		counts, ok := dst[key]
+		if !ok {
+			counts = make(map[string]int, len(nexts))
+			dst[key] = counts
+		}
+		for word, count := range nexts {
+			counts[word] += count
+		}
+	}
+}
+
+// normalizationOf() function returns the normalization
+// of the model, the zero one when it has none.
+func normalizationOf(m *Model) scanner.Normalization {
+	if m.Normalization == nil {
+		return scanner.Normalization{}
+	}
+	return *m.Normalization
+}
+
+// check() function returns an error for unknown levels.
+func (l Level) check() error {
+	switch l {
+	case "", LevelWord, LevelChar:
+		return nil
+	default:
+		return fmt.Errorf("unknown model level %q", l)
+	}
+}
+
+// LevelOrWord() function returns the level of the model,
+// LevelWord for models saved before levels existed.
+func (m *Model) LevelOrWord() Level {
+	if m.Level == "" {
+		return LevelWord
+	}
+	return m.Level
+}
+
+// isLetters() function reports whether the mode is ModeLetters,
+// which models saved before modes existed leave empty.
+func isLetters(mode scanner.Mode) bool {
+	return mode == "" || mode == scanner.ModeLetters
+}
+
+// Continuations() function returns the counts of words following
+// the history, backing off to its shorter suffixes when the
+// longer ones were never seen. It returns nil on a dead end.
+func (m *Model) Continuations(history []string) map[string]int {
+	for k := len(history); k > 0; k-- {
+		key := strings.Join(history[len(history)-k:], " ")
+
+		var nexts map[string]int
+		if k == m.Order {
+			nexts = m.Data[key]
+		} else {
+			nexts = m.Backoff[key]
+		}
+		if len(nexts) > 0 {
+			return nexts
+		}
+	}
+	return nil
+}
+
 // FillWithHash() function fills the model with data
 // from a reader and stores the hash of the data.
+// Content that was already processed is rejected
+// with ErrDuplicateContent and leaves the model untouched.
 func (m *Model) FillWithHash(r io.Reader) error {
-	hasher := sha256.New()
-	tee := io.TeeReader(r, hasher)
-	err := m.Fill(tee)
+	data, err := io.ReadAll(r)
 	if err != nil {
 		return err
 	}
 
-	sum := fmt.Sprintf("%x", hasher.Sum(nil))
+	sum := hashContent(data)
 	if m.Hashes == nil {
 		m.Hashes = make(map[string]struct{})
 	}
 
 	if _, seen := m.Hashes[sum]; seen {
-		return errors.New("duplicate content: already processed")
+		return ErrDuplicateContent
+	}
+
+	if err := m.Fill(bytes.NewReader(data)); err != nil {
+		return err
 	}
 
 	m.Hashes[sum] = struct{}{}
 	return nil
 }
 
-// Fill() function fills the model with data from a reader.
+// hashContent() function returns the hash identifying filled content.
+func hashContent(data []byte) string {
+	return fmt.Sprintf("%x", sha256.Sum256(data))
+}
+
+// Fill() function fills the model with data from a reader,
+// split with the tokenizer of the model's Mode.
 func (m *Model) Fill(r io.Reader) error {
-	scanner, err := scanner.New(r)
+	return m.FillWith(r, nil)
+}
+
+// FillWith() function fills the model like Fill, splitting the text
+// with the tokenizer instead, or the one of the Mode when nil.
+// Only the Mode is saved with the model, so a model filled with
+// a custom tokenizer should be given the same one when filled again.
+func (m *Model) FillWith(r io.Reader, tokenizer scanner.Tokenizer) error {
+	if m.Order < 1 {
+		return errors.New("order must be greater than 0")
+	}
+	if m.Data == nil {
+		m.Data = make(map[string]map[string]int)
+	}
+
+	config := m.scannerConfig()
+	config.Tokenizer = tokenizer
+	ts, err := scanner.NewWithConfig(r, config)
 	if err != nil {
 		return err
 	}
 
+	if m.Starts == nil {
+		m.Starts = make(map[string]int)
+	}
+
 	var history []string
-	for scanner.Scan() {
-		word := scanner.Text()
+	started := false
+	for ts.Scan() {
+		word := ts.Text()
+		for k := 1; k < m.Order && k <= len(history); k++ {
+			m.Add(history[len(history)-k:], word)
+		}
 		if len(history) == m.Order {
+			if !started || history[len(history)-1] == scanner.SentenceBoundary {
+				m.Starts[strings.Join(history, " ")]++
+				started = true
+			}
 			m.Add(history, word)
 			history = history[1:]
 		}
 		history = append(history, word)
 	}
 
-	if err := scanner.Err(); err != nil {
+	if err := ts.Err(); err != nil {
 		return err
 	}
 
 	return nil
 }
 
+// NewScanner() function creates a scanner splitting the text
+// in the model's mode and normalizing it.
+func (m *Model) NewScanner(r io.Reader) (*scanner.TextScanner, error) {
+	return scanner.NewWithConfig(r, m.scannerConfig())
+}
+
+// scannerConfig() function returns the configuration
+// of the model's mode and normalization.
+func (m *Model) scannerConfig() scanner.TextScannerConfig {
+	config := scanner.DefaultConfig(m.Mode)
+	if m.Normalization != nil {
+		config.Normalization = *m.Normalization
+	}
+	return config
+}
+
 // EncodeJSON() function encodes the model to JSON.
 func (m *Model) EncodeJSON(w io.Writer) error {
 	encoder := json.NewEncoder(w)
@@ -98,41 +340,54 @@ func (m *Model) DecodeJSON(r io.Reader) error {
 	return decoder.Decode(m)
 }
 
-// CompressZstd() function compresses the model using Zstandard.
-func (m *Model) CompressZstd(w io.Writer) error {
-	encoder, err := zstd.NewWriter(w)
+// CompressZstd() function writes the JSON encoded model to a writer
+// using Zstandard compression at the given level, from 1 to 22,
+// or the default level when 0.
+func (m *Model) CompressZstd(w io.Writer, level int) error {
+	encoder, err := newZstdWriter(w, level)
 	if err != nil {
 		return err
 	}
-	defer encoder.Close()
-	return nil
+	if err := m.EncodeJSON(encoder); err != nil {
+		encoder.Close()
+		return err
+	}
+	return encoder.Close()
 }
 
-// DecompressZstd() function decompresses the model using Zstandard.
+// DecompressZstd() function reads the JSON encoded model
+// from a reader using Zstandard decompression.
 func (m *Model) DecompressZstd(r io.Reader) error {
 	decoder, err := zstd.NewReader(r)
 	if err != nil {
 		return err
 	}
 	defer decoder.Close()
-	return nil
+	return m.DecodeJSON(decoder)
 }
 
-// Save() function writes the JSON encoded model
-// to a writer using Zstandard compression.
-func (m *Model) Save(w io.Writer) error {
-	encoder, err := zstd.NewWriter(w)
+// CompressGzip() function writes the JSON encoded model to a writer
+// using gzip compression at the given level, from 1 to 9,
+// or the default level when 0.
+func (m *Model) CompressGzip(w io.Writer, level int) error {
+	if level == 0 {
+		level = gzip.DefaultCompression
+	}
+	encoder, err := gzip.NewWriterLevel(w, level)
 	if err != nil {
 		return err
 	}
-	defer encoder.Close()
-	return m.EncodeJSON(encoder)
+	if err := m.EncodeJSON(encoder); err != nil {
+		encoder.Close()
+		return err
+	}
+	return encoder.Close()
 }
 
-// Load() function reads the JSON encoded model
-// from a reader using Zstandard decompression.
-func (m *Model) Load(r io.Reader) error {
-	decoder, err := zstd.NewReader(r)
+// DecompressGzip() function reads the JSON encoded model
+// from a reader using gzip decompression.
+func (m *Model) DecompressGzip(r io.Reader) error {
+	decoder, err := gzip.NewReader(r)
 	if err != nil {
 		return err
 	}
@@ -140,6 +395,119 @@ func (m *Model) Load(r io.Reader) error {
 	return m.DecodeJSON(decoder)
 }
 
+// Load() function reads a model saved with any of the codecs,
+// detected from its first bytes, and validates it.
+// If the model already has an order, the loaded
+// model must have the same one.
+func (m *Model) Load(r io.Reader) error {
+	br := bufio.NewReader(r)
+	magic, _ := br.Peek(len(fileMagic))
+
+	codec, err := DetectCodec(magic)
+	if err != nil {
+		return err
+	}
+
+	var loaded *Model
+	if codec == CodecBinary {
+		loaded, err = loadFile(br)
+	} else {
+		loaded, err = loadJSON(br, codec)
+	}
+	if err != nil {
+		return err
+	}
+	if m.Order != 0 && m.Order != loaded.Order {
+		return fmt.Errorf("%w: expected %d, got %d", ErrOrderMismatch, m.Order, loaded.Order)
+	}
+
+	*m = *loaded
+	return nil
+}
+
+// loadJSON() function reads a JSON encoded model
+// compressed with the codec and validates it.
+func loadJSON(r io.Reader, codec Codec) (*Model, error) {
+	var loaded Model
+	var err error
+	switch codec {
+	case CodecZstd:
+		err = loaded.DecompressZstd(r)
+	case CodecGzip:
+		err = loaded.DecompressGzip(r)
+	default:
+		err = loaded.DecodeJSON(r)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrCorruptModel, err)
+	}
+
+	if loaded.Data == nil {
+		loaded.Data = make(map[string]map[string]int)
+	}
+	if loaded.Backoff == nil {
+		loaded.Backoff = make(map[string]map[string]int)
+	}
+	if loaded.Starts == nil {
+		loaded.Starts = make(map[string]int)
+	}
+	if loaded.Hashes == nil {
+		loaded.Hashes = make(map[string]struct{})
+	}
+	if err := loaded.validate(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrCorruptModel, err)
+	}
+	return &loaded, nil
+}
+
+// validate() function checks the order, mode and normalization
+// of the model and the lengths and counts of its histories.
+func (m *Model) validate() error {
+	if m.Order < 1 {
+		return fmt.Errorf("invalid order %d", m.Order)
+	}
+	if err := m.Level.check(); err != nil {
+		return err
+	}
+	if _, err := scanner.NewTokenizer(m.Mode); err != nil {
+		return err
+	}
+	if m.Normalization != nil {
+		if _, err := m.Normalization.Pipeline(); err != nil {
+			return err
+		}
+	}
+
+	sections := []struct {
+		data           map[string]map[string]int
+		minLen, maxLen int
+	}{
+		{m.Data, m.Order, m.Order},
+		{m.Backoff, 1, m.Order - 1},
+	}
+	for _, section := range sections {
+		for key, nexts := range section.data {
+			if n := len(strings.Split(key, " ")); n < section.minLen || n > section.maxLen {
+				return fmt.Errorf("history %q of %d words in a model of order %d", key, n, m.Order)
+			}
+			for word, count := range nexts {
+				if count < 1 {
+					return fmt.Errorf("count %d of %q after %q", count, word, key)
+				}
+			}
+		}
+	}
+	for key, count := range m.Starts {
+		if n := len(strings.Split(key, " ")); n != m.Order {
+			return fmt.Errorf("start state %q of %d words in a model of order %d", key, n, m.Order)
+		}
+		if count < 1 {
+			return fmt.Errorf("count %d of start state %q", count, key)
+		}
+	}
+	return nil
+}
+
 // IsEmpty() function returns true if the model is empty.
 func (m *Model) IsEmpty() bool {
 	return len(m.Data) == 0 || m.Data == nil
@@ -148,4 +516,6 @@ func (m *Model) IsEmpty() bool {
 // Clear() function clears the model.
 func (m *Model) Clear() {
 	m.Data = make(map[string]map[string]int)
+	m.Backoff = make(map[string]map[string]int)
+	m.Starts = make(map[string]int)
 }
@@ -0,0 +1,36 @@
+package ngram
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// commaTokenizer splits the text at commas, keeping spaces in tokens.
+type commaTokenizer struct{}
+
+func (commaTokenizer) Split(data []byte, atEOF bool) (int, []byte, error) {
+	if i := bytes.IndexByte(data, ','); i >= 0 {
+		return i + 1, data[:i], nil
+	}
+	if atEOF && len(data) > 0 {
+		return len(data), data, nil
+	}
+	return 0, nil, nil
+}
+
+func TestFillWithTokenizer(t *testing.T) {
+	m := NewModel(1)
+	if err := m.FillWith(strings.NewReader("New York,Los Angeles,New York"), commaTokenizer{}); err != nil {
+		t.Fatal(err)
+	}
+	// The lowercasing of the default mode still applies
+	want := map[string]map[string]int{
+		"new york":    {"los angeles": 1},
+		"los angeles": {"new york": 1},
+	}
+	if !reflect.DeepEqual(m.Data, want) {
+		t.Errorf("Data = %v, want %v", m.Data, want)
+	}
+}
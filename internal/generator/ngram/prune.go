@@ -0,0 +1,165 @@
+package ngram
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/abilun/keybon/internal/scanner"
+)
+
+// WordCounts() function returns how often each word occurs in the
+// texts the model was filled with: as a continuation of a full
+// history or within a start state.
+func (m *Model) WordCounts() map[string]int {
+	counts := make(map[string]int)
+	for _, nexts := range m.Data {
+		for word, count := range nexts {
+			counts[word] += count
+		}
+	}
+	for key, count := range m.Starts {
+		for _, word := range strings.Split(key, " ") {
+			counts[word] += count
+		}
+	}
+	return counts
+}
+
+// Prune() function removes the continuations and start states counted
+// fewer than minCount times, and the histories left without any.
+// It returns the number of continuations removed.
+func (m *Model) Prune(minCount int) int {
+	removed := pruneCounts(m.Data, minCount) + pruneCounts(m.Backoff, minCount)
+	for key, count := range m.Starts {
+		if count < minCount {
+			delete(m.Starts, key)
+		}
+	}
+	m.pruneStarts()
+	return removed
+}
+
+// pruneCounts() function removes the continuations counted fewer
+// than minCount times and returns how many were removed.
+func pruneCounts(data map[string]map[string]int, minCount int) int {
+	removed := 0
+	for key, nexts := range data {
+		for word, count := range nexts {
+			if count < minCount {
+				delete(nexts, word)
+				removed++
+			}
+		}
+		if len(nexts) == 0 {
+			delete(data, key)
+		}
+	}
+	return removed
+}
+
+// pruneStarts() function removes the start states
+// that are no longer histories of the model.
+func (m *Model) pruneStarts() {
+	for key := range m.Starts {
+		if len(m.Data[key]) == 0 {
+			delete(m.Starts, key)
+		}
+	}
+}
+
+// reservedTokens() function returns the tokens with a special
+// meaning in models of the level, which generation relies on.
+func reservedTokens(level Level) []string {
+	if level == LevelChar {
+		return []string{WordStart, WordEnd}
+	}
+	return []string{scanner.SentenceBoundary}
+}
+
+// CapVocab() function keeps the n most frequent words of the model,
+// as counted by WordCounts, ties broken alphabetically, and its
+// reserved tokens, such as scanner.SentenceBoundary. Rare words
+// are replaced by unknown, summing the counts they are merged into,
+// or, when unknown is empty, the histories and continuations
+// containing them are removed. It returns the number of rare words.
+func (m *Model) CapVocab(n int, unknown string) (int, error) {
+	if n < 1 {
+		return 0, errors.New("vocabulary size must be greater than 0")
+	}
+	if strings.Contains(unknown, " ") {
+		return 0, errors.New("unknown word must not contain spaces")
+	}
+
+	keep := make(map[string]struct{}, n)
+	for _, token := range reservedTokens(m.LevelOrWord()) {
+		if unknown == token {
+			return 0, fmt.Errorf("unknown word must not be the reserved token %q", token)
+		}
+		keep[token] = struct{}{}
+	}
+
+	var words []string
+	for _, word := range sortedByCount(m.WordCounts()) {
+		if _, ok := keep[word]; !ok {
+			words = append(words, word)
+		}
+	}
+	if len(words) <= n {
+		return 0, nil
+	}
+	for _, word := range words[:n] {
+		keep[word] = struct{}{}
+	}
+
+	// mapWord returns the word to store a word as, false to drop it
+	mapWord := func(word string) (string, bool) {
+		if _, ok := keep[word]; ok {
+			return word, true
+		}
+		return unknown, unknown != ""
+	}
+	mapKey := func(key string) (string, bool) {
+		words := strings.Split(key, " ")
+		for i, word := range words {
+			mapped, ok := mapWord(word)
+			if !ok {
+				return "", false
+			}
+			words[i] = mapped
+		}
+		return strings.Join(words, " "), true
+	}
+	mapCounts := func(data map[string]map[string]int) map[string]map[string]int {
+		mapped := make(map[string]map[string]int, len(data))
+		for key, nexts := range data {
+			mappedKey, ok := mapKey(key)
+			if !ok {
+				continue
+			}
+			for word, count := range nexts {
+				mappedWord, ok := mapWord(word)
+				if !ok {
+					continue
+				}
+				if mapped[mappedKey] == nil {
+					mapped[mappedKey] = make(map[string]int)
+				}
+				mapped[mappedKey][mappedWord] += count
+			}
+		}
+		return mapped
+	}
+
+	m.Data = mapCounts(m.Data)
+	m.Backoff = mapCounts(m.Backoff)
+	starts := make(map[string]int, len(m.Starts))
+	for key, count := range m.Starts {
+		if mappedKey, ok := mapKey(key); ok {
+			starts[mappedKey] += count
+		}
+	}
+	m.Starts = starts
+	m.pruneStarts()
+	return len(words) - n, nil
+}
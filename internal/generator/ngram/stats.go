@@ -0,0 +1,193 @@
+package ngram
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strings"
+)
+
+// Stats summarizes a model.
+type Stats struct {
+	Order int
+	// Histories are the full-order histories, BackoffHistories the shorter ones
+	Histories, BackoffHistories int
+	VocabSize                   int
+	// Transitions are the distinct history and continuation pairs
+	// of the full-order histories, Total the sum of their counts
+	Transitions, Total int
+	Starts             int
+	// Branching counts the full-order histories by their number of
+	// continuations: Branching[i] those with 2^i to 2^(i+1)-1
+	Branching []int
+	// DeadEnds are the histories generation can reach, but has no
+	// continuation for, even after backing off, so it has to restart
+	DeadEnds int
+
+	TopHistories   []HistoryCount
+	TopTransitions []TransitionCount
+}
+
+// HistoryCount is a history with the total count of its continuations.
+type HistoryCount struct {
+	History       string
+	Count         int
+	Continuations int
+}
+
+// TransitionCount is a continuation of a history with its count.
+type TransitionCount struct {
+	History, Word string
+	Count         int
+}
+
+// MeanBranching() function returns the average number
+// of continuations of the full-order histories.
+func (s *Stats) MeanBranching() float64 {
+	if s.Histories == 0 {
+		return 0
+	}
+	return float64(s.Transitions) / float64(s.Histories)
+}
+
+// Stats() function summarizes the model, listing
+// the top most common histories and transitions.
+func (m *Model) Stats(top int) Stats {
+	s := Stats{
+		Order:            m.Order,
+		Histories:        len(m.Data),
+		BackoffHistories: len(m.Backoff),
+		VocabSize:        len(m.WordCounts()),
+		Starts:           len(m.Starts),
+	}
+
+	totals := make(map[string]int, len(m.Data))
+	deadEnds := make(map[string]struct{})
+	var transitions []TransitionCount
+	for key, nexts := range m.Data {
+		history := strings.Split(key, " ")
+		for word, count := range nexts {
+			totals[key] += count
+			transitions = append(transitions, TransitionCount{key, word, count})
+
+			next := append(history[1:len(history):len(history)], word)
+			if m.Continuations(next) == nil {
+				deadEnds[strings.Join(next, " ")] = struct{}{}
+			}
+		}
+
+		bucket := 0
+		for n := len(nexts); n > 1; n >>= 1 {
+			bucket++
+		}
+		for len(s.Branching) <= bucket {
+			s.Branching = append(s.Branching, 0)
+		}
+		s.Branching[bucket]++
+	}
+	s.Transitions = len(transitions)
+	s.DeadEnds = len(deadEnds)
+
+	for _, key := range sortedByCount(totals) {
+		s.Total += totals[key]
+		if len(s.TopHistories) < top {
+			s.TopHistories = append(s.TopHistories,
+				HistoryCount{key, totals[key], len(m.Data[key])})
+		}
+	}
+
+	sort.Slice(transitions, func(i, j int) bool {
+		a, b := transitions[i], transitions[j]
+		if a.Count != b.Count {
+			return a.Count > b.Count
+		}
+		if a.History != b.History {
+			return a.History < b.History
+		}
+		return a.Word < b.Word
+	})
+	s.TopTransitions = transitions[:min(top, len(transitions))]
+	return s
+}
+
+// Evaluation is how well a model predicts a text.
+type Evaluation struct {
+	// Tokens are the tokens of the text, Unknown those not in the vocabulary
+	Tokens, Unknown int
+	// Seen are the tokens the model has seen after their full history
+	Seen int
+	// LogLikelihood is the sum of the natural logarithms
+	// of the probabilities of the tokens
+	LogLikelihood float64
+}
+
+// CrossEntropy() function returns the average number
+// of bits per token the model needs for the text.
+func (e *Evaluation) CrossEntropy() float64 {
+	if e.Tokens == 0 {
+		return 0
+	}
+	return -e.LogLikelihood / float64(e.Tokens) / math.Ln2
+}
+
+// Perplexity() function returns the perplexity of the model on the text:
+// the number of words it chooses between on average, lower is better.
+func (e *Evaluation) Perplexity() float64 {
+	return math.Exp2(e.CrossEntropy())
+}
+
+// Evaluate() function scores how well the model predicts a held-out text,
+// split and normalized like the texts the model was filled with.
+// Every token is given the probability of following its history,
+// backing off like generation does, down to the word counts for
+// unseen histories. Counts are add-one smoothed over the vocabulary
+// and an unknown word, so unseen tokens are not impossible.
+// Character level models are rejected.
+func (m *Model) Evaluate(r io.Reader) (Evaluation, error) {
+	var e Evaluation
+	if m.LevelOrWord() != LevelWord {
+		return e, fmt.Errorf("%w: only word level models can be evaluated on text", ErrLevelMismatch)
+	}
+
+	ts, err := m.NewScanner(r)
+	if err != nil {
+		return e, err
+	}
+
+	words := m.WordCounts()
+	total := 0
+	for _, count := range words {
+		total += count
+	}
+	vocab := float64(len(words) + 1)
+
+	var history []string
+	for ts.Scan() {
+		word := ts.Text()
+
+		nexts, sum := m.Continuations(history), 0
+		if nexts == nil {
+			nexts, sum = words, total
+		} else {
+			for _, count := range nexts {
+				sum += count
+			}
+		}
+		e.LogLikelihood += math.Log((float64(nexts[word]) + 1) / (float64(sum) + vocab))
+
+		e.Tokens++
+		if _, ok := words[word]; !ok {
+			e.Unknown++
+		}
+		if len(history) == m.Order && m.Data[strings.Join(history, " ")][word] > 0 {
+			e.Seen++
+		}
+
+		if len(history) == m.Order {
+			history = history[1:]
+		}
+		history = append(history, word)
+	}
+	return e, ts.Err()
+}
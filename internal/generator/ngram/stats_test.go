@@ -0,0 +1,58 @@
+package ngram
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestEvaluate(t *testing.T) {
+	m := testModel(t, 2)
+
+	seen, err := m.Evaluate(strings.NewReader(testText))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if seen.Tokens != 28 || seen.Unknown != 0 {
+		t.Errorf("tokens = %d, unknown = %d, want 28 and 0", seen.Tokens, seen.Unknown)
+	}
+	// Only the first two tokens lack a full history
+	if seen.Seen != seen.Tokens-2 {
+		t.Errorf("seen = %d, want %d", seen.Seen, seen.Tokens-2)
+	}
+
+	unseen, err := m.Evaluate(strings.NewReader("purple elephants dance quietly under the moon"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if unseen.Unknown != 6 || unseen.Seen != 0 {
+		t.Errorf("unknown = %d, seen = %d, want 6 and 0", unseen.Unknown, unseen.Seen)
+	}
+
+	vocab := float64(len(m.WordCounts()) + 1)
+	if p := seen.Perplexity(); p <= 1 || p >= vocab {
+		t.Errorf("perplexity of the training text = %f, want between 1 and %f", p, vocab)
+	}
+	if seen.Perplexity() >= unseen.Perplexity() {
+		t.Errorf("perplexity of the training text %f is not below that of an unseen text %f",
+			seen.Perplexity(), unseen.Perplexity())
+	}
+}
+
+func TestEvaluateEmptyText(t *testing.T) {
+	e, err := testModel(t, 2).Evaluate(strings.NewReader(""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e.Tokens != 0 || e.CrossEntropy() != 0 || e.Perplexity() != 1 {
+		t.Errorf("evaluation of an empty text = %+v, perplexity %f", e, e.Perplexity())
+	}
+}
+
+func TestEvaluateRejectsCharacterModels(t *testing.T) {
+	m := testModel(t, 2)
+	m.Level = LevelChar
+	if _, err := m.Evaluate(strings.NewReader(testText)); !errors.Is(err, ErrLevelMismatch) {
+		t.Errorf("Evaluate() error = %v, want ErrLevelMismatch", err)
+	}
+}
@@ -0,0 +1,183 @@
+package ngram
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"sync"
+
+	"github.com/abilun/keybon/internal/corpus"
+	"golang.org/x/sync/errgroup"
+)
+
+// Progress reports the files a Trainer has read.
+type Progress struct {
+	// Path is the file just read
+	Path string
+	// Err is why the file was skipped, ErrDuplicateContent for duplicates
+	Err error
+
+	Files, TotalFiles int
+	// Bytes are the sizes of the files read, as stored on disk
+	Bytes, TotalBytes int64
+}
+
+// Trainer fills a model from many files on a pool of workers.
+// Every worker fills a partial model of its own, the partial
+// models are merged into the model once all files are read.
+// Files whose content the model or another file already
+// contained are skipped.
+type Trainer struct {
+	// Workers defaults to the number of CPUs
+	Workers int
+	// Open opens a file, defaults to corpus.Open,
+	// which decompresses gzip and zstd files
+	Open func(path string) (io.ReadCloser, error)
+	// Fill fills a model from a reader, defaults to (*Model).Fill
+	Fill func(m *Model, r io.Reader) error
+	// Progress is called after every file, by one worker at a time
+	Progress func(Progress)
+}
+
+// Train() function fills the model from the files at the paths.
+// On error the model is left untouched.
+func (t *Trainer) Train(m *Model, paths []string) error {
+	if len(paths) == 0 {
+		return nil
+	}
+
+	open := t.Open
+	if open == nil {
+		open = corpus.Open
+	}
+	fill := t.Fill
+	if fill == nil {
+		fill = (*Model).Fill
+	}
+	workers := t.Workers
+	if workers < 1 {
+		workers = runtime.NumCPU()
+	}
+	workers = min(workers, len(paths))
+
+	var mu sync.Mutex
+	progress := Progress{TotalFiles: len(paths)}
+	sizes := make([]int64, len(paths))
+	for i, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return err
+		}
+		sizes[i] = info.Size()
+		progress.TotalBytes += sizes[i]
+	}
+
+	// claimed holds the hashes of the files being read, so
+	// duplicates are skipped even when read by different workers
+	claimed := make(map[string]struct{})
+	claim := func(sum string) bool {
+		mu.Lock()
+		defer mu.Unlock()
+		if _, ok := m.Hashes[sum]; ok {
+			return false
+		}
+		if _, ok := claimed[sum]; ok {
+			return false
+		}
+		claimed[sum] = struct{}{}
+		return true
+	}
+	report := func(i int, err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		progress.Path, progress.Err = paths[i], err
+		progress.Files++
+		progress.Bytes += sizes[i]
+		if t.Progress != nil {
+			t.Progress(progress)
+		}
+	}
+
+	g, ctx := errgroup.WithContext(context.Background())
+	jobs := make(chan int)
+	g.Go(func() error {
+		defer close(jobs)
+		for i := range paths {
+			select {
+			case jobs <- i:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+		return nil
+	})
+
+	partials := make([]*Model, workers)
+	for w := 0; w < workers; w++ {
+		partial := m.empty()
+		partials[w] = partial
+		g.Go(func() error {
+			for i := range jobs {
+				err := fillFile(partial, paths[i], open, fill, claim)
+				if err != nil && !errors.Is(err, ErrDuplicateContent) {
+					return err
+				}
+				report(i, err)
+			}
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return err
+	}
+
+	for _, partial := range partials {
+		if err := m.Merge(partial); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// fillFile() function fills the partial model from the file at the path,
+// unless claim rejects its content as a duplicate.
+func fillFile(partial *Model, path string,
+	open func(string) (io.ReadCloser, error),
+	fill func(*Model, io.Reader) error,
+	claim func(sum string) bool,
+) error {
+	file, err := open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open file %q: %w", path, err)
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return fmt.Errorf("failed to read file %q: %w", path, err)
+	}
+
+	sum := hashContent(data)
+	if !claim(sum) {
+		return ErrDuplicateContent
+	}
+	if err := fill(partial, bytes.NewReader(data)); err != nil {
+		return fmt.Errorf("failed to train on %q: %w", path, err)
+	}
+	partial.Hashes[sum] = struct{}{}
+	return nil
+}
+
+// empty() function returns an empty model with the same
+// order, level, mode and normalization as the model.
+func (m *Model) empty() *Model {
+	e := NewModel(m.Order)
+	e.Level = m.Level
+	e.Mode = m.Mode
+	e.Normalization = m.Normalization
+	return e
+}
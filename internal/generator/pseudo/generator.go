@@ -0,0 +1,104 @@
+package pseudo
+
+import (
+	"errors"
+	"math/rand"
+	"strings"
+	"time"
+
+	"github.com/abilun/keybon/internal/generator/ngram"
+)
+
+const (
+	// DefaultMinLength and DefaultMaxLength bound the length
+	// of the words, unless SetLength is called.
+	DefaultMinLength = 3
+	DefaultMaxLength = 8
+	// maxAttempts bounds the number of words generated
+	// while looking for one of the requested length.
+	maxAttempts = 100
+)
+
+// Generator emits invented, but pronounceable words
+// by walking the letter transitions of a character Model.
+type Generator struct {
+	*Model
+	minLength int
+	maxLength int
+	nextFunc  ngram.ChoiceFunc
+	rng       *rand.Rand
+}
+
+// NewFromModel() function creates a new Generator
+// backed by an existing, e.g. loaded, model.
+func NewFromModel(m *Model) *Generator {
+	return &Generator{
+		Model:     m,
+		minLength: DefaultMinLength,
+		maxLength: DefaultMaxLength,
+		nextFunc:  ngram.WeightedChoice,
+		rng:       rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+// SetLength() function sets the allowed length of generated words in letters.
+func (g *Generator) SetLength(minLength, maxLength int) error {
+	if minLength < 1 || maxLength < minLength {
+		return errors.New("invalid word length range")
+	}
+	g.minLength = minLength
+	g.maxLength = maxLength
+	return nil
+}
+
+// NextFunc() function sets the function used to choose
+// the next letter, nil for ngram.WeightedChoice.
+func (g *Generator) NextFunc(nextFunc ngram.ChoiceFunc) {
+	if nextFunc == nil {
+		nextFunc = ngram.WeightedChoice
+	}
+	g.nextFunc = nextFunc
+}
+
+// Seed() function makes the generated words reproducible.
+func (g *Generator) Seed(seed int64) {
+	g.rng = rand.New(rand.NewSource(seed))
+}
+
+// Next() function returns a new pseudo-word within the length range.
+func (g *Generator) Next() (string, error) {
+	if g.Model.IsEmpty() {
+		return "", errors.New("model is empty")
+	}
+
+	for i := 0; i < maxAttempts; i++ {
+		if word, ok := g.word(); ok {
+			return word, nil
+		}
+	}
+	return "", errors.New("no word of the requested length")
+}
+
+// word() function walks the model from the start of a word to its end.
+// It reports false if the word does not fit the length range.
+func (g *Generator) word() (string, bool) {
+	var b strings.Builder
+	history := startHistory(g.Order)
+
+	for length := 0; length <= g.maxLength; {
+		nexts := g.Model.Data[strings.Join(history, " ")]
+		if len(nexts) == 0 {
+			return "", false
+		}
+
+		letter := g.nextFunc(g.rng, nexts)
+		if letter == wordEnd {
+			return b.String(), length >= g.minLength
+		}
+
+		b.WriteString(letter)
+		length++
+		history = append(history[1:], letter)
+	}
+	return "", false
+}
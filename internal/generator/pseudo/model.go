@@ -0,0 +1,78 @@
+package pseudo
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/abilun/keybon/internal/generator/ngram"
+	"github.com/abilun/keybon/internal/scanner"
+)
+
+const (
+	// wordStart pads the history before the first letter of a word.
+	wordStart = ngram.WordStart
+	// wordEnd is the continuation that ends a word.
+	wordEnd = ngram.WordEnd
+)
+
+// Model is a character level n-gram model: histories are
+// the last Order letters of a word, continuations are letters
+// or the end of the word. It is stored, saved and loaded
+// the same way as a word level ngram.Model, with ngram.LevelChar.
+type Model struct {
+	*ngram.Model
+}
+
+// Fill() function fills the model with the letter sequences of the
+// words read from a reader, split in the mode of the model and
+// normalized by its normalization. Only ModeLetters is supported.
+func (m *Model) Fill(r io.Reader) error {
+	if m.Level == ngram.LevelWord {
+		return fmt.Errorf("%w: expected a %s level model, got %s",
+			ngram.ErrLevelMismatch, ngram.LevelChar, m.LevelOrWord())
+	}
+	if m.Mode != "" && m.Mode != scanner.ModeLetters {
+		return fmt.Errorf("character level models cannot be filled in %s mode", m.Mode)
+	}
+
+	scanner, err := m.NewScanner(r)
+	if err != nil {
+		return err
+	}
+
+	for scanner.Scan() {
+		if err := m.AddWord(scanner.Text()); err != nil {
+			return err
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// AddWord() function adds the letter transitions of a single word.
+func (m *Model) AddWord(word string) error {
+	history := startHistory(m.Order)
+	letters := append(strings.Split(word, ""), wordEnd)
+
+	for _, letter := range letters {
+		if err := m.Add(history, letter); err != nil {
+			return err
+		}
+		history = append(history[1:], letter)
+	}
+	return nil
+}
+
+// startHistory() function returns the history before the first letter.
+func startHistory(order int) []string {
+	history := make([]string, order)
+	for i := range history {
+		history[i] = wordStart
+	}
+	return history
+}
@@ -0,0 +1,191 @@
+package quote
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/abilun/keybon/internal/generator"
+)
+
+// Length is a bucket of passage lengths, as used by monkeytype.
+type Length string
+
+const (
+	Short  Length = "short"
+	Medium Length = "medium"
+	Long   Length = "long"
+	Thicc  Length = "thicc"
+)
+
+// LengthOf() function returns the bucket of a passage by its length in characters.
+func LengthOf(text string) Length {
+	switch n := utf8.RuneCountInString(text); {
+	case n <= 100:
+		return Short
+	case n <= 300:
+		return Medium
+	case n <= 600:
+		return Long
+	default:
+		return Thicc
+	}
+}
+
+// Quote is a passage from the quotes corpus.
+type Quote struct {
+	Text   string `json:"text"`
+	Author string `json:"author"`
+	Source string `json:"source"`
+}
+
+// Attribution() function returns the author and the source of the quote.
+func (q Quote) Attribution() string {
+	switch {
+	case q.Author != "" && q.Source != "":
+		return q.Author + ", " + q.Source
+	case q.Author != "":
+		return q.Author
+	default:
+		return q.Source
+	}
+}
+
+// Generator serves whole quotes, to be typed verbatim,
+// in a random order without repeating until all were served.
+type Generator struct {
+	quotes  []Quote
+	lengths map[Length]struct{}
+
+	bag   generator.Bag[Quote]
+	words []string
+	rng   *rand.Rand
+}
+
+func New() *Generator {
+	return &Generator{
+		rng: rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+// Seed() function makes the order of the quotes reproducible.
+func (g *Generator) Seed(seed int64) {
+	g.rng = rand.New(rand.NewSource(seed))
+	g.bag.Reset()
+}
+
+// SetLengths() function restricts the quotes to the given length buckets.
+// No buckets means all lengths.
+func (g *Generator) SetLengths(lengths ...Length) {
+	g.lengths = nil
+	if len(lengths) > 0 {
+		g.lengths = make(map[Length]struct{}, len(lengths))
+		for _, l := range lengths {
+			g.lengths[l] = struct{}{}
+		}
+	}
+	g.bag.Reset()
+}
+
+// Fill() function reads quotes from a reader, either a JSON array
+// of objects with text, author and source, or TSV lines with
+// the same columns. The format is detected from the content.
+func (g *Generator) Fill(r io.Reader) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+
+	var quotes []Quote
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '[' {
+		if err := json.Unmarshal(trimmed, &quotes); err != nil {
+			return fmt.Errorf("invalid JSON quotes: %w", err)
+		}
+	} else {
+		if quotes, err = parseTSV(data); err != nil {
+			return fmt.Errorf("invalid TSV quotes: %w", err)
+		}
+	}
+
+	for _, q := range quotes {
+		q.Text = strings.Join(strings.Fields(q.Text), " ")
+		if q.Text != "" {
+			g.quotes = append(g.quotes, q)
+		}
+	}
+	g.bag.Reset()
+	return nil
+}
+
+// parseTSV() function reads "text<TAB>author<TAB>source" lines.
+// Lines may be as long as the data, which is already in memory.
+func parseTSV(data []byte) ([]Quote, error) {
+	var quotes []Quote
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(nil, max(len(data)+1, bufio.MaxScanTokenSize))
+	for scanner.Scan() {
+		fields := strings.Split(scanner.Text(), "\t")
+		// Skip the header, if any
+		if fields[0] == "text" {
+			continue
+		}
+		q := Quote{Text: fields[0]}
+		if len(fields) > 1 {
+			q.Author = strings.TrimSpace(fields[1])
+		}
+		if len(fields) > 2 {
+			q.Source = strings.TrimSpace(fields[2])
+		}
+		quotes = append(quotes, q)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return quotes, nil
+}
+
+// NextPassage() function returns the next quote with its attribution.
+func (g *Generator) NextPassage() (generator.Passage, error) {
+	q, err := g.nextQuote()
+	if err != nil {
+		return generator.Passage{}, err
+	}
+	return generator.Passage{
+		Text:        q.Text,
+		Attribution: q.Attribution(),
+	}, nil
+}
+
+// Next() function returns the words of the quotes one by one.
+func (g *Generator) Next() (string, error) {
+	if len(g.words) == 0 {
+		q, err := g.nextQuote()
+		if err != nil {
+			return "", err
+		}
+		g.words = strings.Fields(q.Text)
+	}
+	word := g.words[0]
+	g.words = g.words[1:]
+	return word, nil
+}
+
+// nextQuote() function draws the next quote from a shuffled bag
+// of the matching quotes, refilling the bag once it is empty.
+func (g *Generator) nextQuote() (Quote, error) {
+	q, ok := g.bag.Draw(g.rng, g.quotes, func(q Quote) bool {
+		_, ok := g.lengths[LengthOf(q.Text)]
+		return ok || g.lengths == nil
+	})
+	if !ok {
+		return Quote{}, errors.New("no quotes of the requested length")
+	}
+	return q, nil
+}
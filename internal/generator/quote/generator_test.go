@@ -0,0 +1,104 @@
+package quote
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestFillTSV(t *testing.T) {
+	g := New()
+	tsv := "text\tauthor\tsource\n" +
+		"Be  yourself.\t Oscar Wilde \t\n" +
+		"No attribution\n" +
+		"\t\t\n" +
+		"Stay hungry.\tSteve Jobs\tStanford\n"
+	if err := g.Fill(strings.NewReader(tsv)); err != nil {
+		t.Fatal(err)
+	}
+	want := []Quote{
+		{Text: "Be yourself.", Author: "Oscar Wilde"},
+		{Text: "No attribution"},
+		{Text: "Stay hungry.", Author: "Steve Jobs", Source: "Stanford"},
+	}
+	if !reflect.DeepEqual(g.quotes, want) {
+		t.Errorf("quotes = %+v, want %+v", g.quotes, want)
+	}
+}
+
+func TestFillJSON(t *testing.T) {
+	g := New()
+	json := `[{"text": "Hello\nworld", "author": "A"}, {"text": " "}]`
+	if err := g.Fill(strings.NewReader(json)); err != nil {
+		t.Fatal(err)
+	}
+	if want := []Quote{{Text: "Hello world", Author: "A"}}; !reflect.DeepEqual(g.quotes, want) {
+		t.Errorf("quotes = %+v, want %+v", g.quotes, want)
+	}
+}
+
+func TestFillLongLine(t *testing.T) {
+	// Longer than the 64 KiB bufio.Scanner allows by default
+	long := strings.Repeat("word ", 100<<10/5)
+	g := New()
+	if err := g.Fill(strings.NewReader(long + "\tAuthor\n")); err != nil {
+		t.Fatal(err)
+	}
+	if len(g.quotes) != 1 || g.quotes[0].Author != "Author" {
+		t.Errorf("long quote not read whole: %d quotes", len(g.quotes))
+	}
+}
+
+func TestFillReadError(t *testing.T) {
+	errRead := errors.New("read failed")
+	if err := New().Fill(iotest.ErrReader(errRead)); !errors.Is(err, errRead) {
+		t.Errorf("Fill() error = %v, want %v", err, errRead)
+	}
+}
+
+func TestLengthOf(t *testing.T) {
+	tests := []struct {
+		n    int
+		want Length
+	}{
+		{1, Short}, {100, Short}, {101, Medium}, {300, Medium},
+		{301, Long}, {600, Long}, {601, Thicc},
+	}
+	for _, tt := range tests {
+		// Lengths are counted in characters, not bytes
+		if got := LengthOf(strings.Repeat("é", tt.n)); got != tt.want {
+			t.Errorf("LengthOf(%d characters) = %s, want %s", tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestNextPassageCyclesThroughQuotes(t *testing.T) {
+	g := New()
+	g.Seed(1)
+	tsv := "one\tA\ntwo\tB\n" + strings.Repeat("long ", 30) + "\tC\n"
+	if err := g.Fill(strings.NewReader(tsv)); err != nil {
+		t.Fatal(err)
+	}
+	g.SetLengths(Short)
+
+	for round := 0; round < 3; round++ {
+		seen := make(map[string]bool)
+		for i := 0; i < 2; i++ {
+			p, err := g.NextPassage()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if LengthOf(p.Text) != Short || seen[p.Text] {
+				t.Fatalf("round %d: got %q after %v", round, p.Text, seen)
+			}
+			seen[p.Text] = true
+		}
+	}
+
+	g.SetLengths(Thicc)
+	if _, err := g.NextPassage(); err == nil {
+		t.Error("no error without quotes of the requested length")
+	}
+}
@@ -0,0 +1,126 @@
+package scanner
+
+import (
+	"bytes"
+	"unicode"
+	"unicode/utf8"
+)
+
+// IdentifiersTokenizer splits source code into identifiers and keywords,
+// keeping underscores, digits and qualified names such as json.Unmarshal.
+// Number literals and everything else are skipped. With SplitParts
+// the identifiers are further split into their camelCase and snake_case parts.
+type IdentifiersTokenizer struct {
+	SplitParts bool
+
+	// parts are the identifier parts not returned yet
+	parts [][]byte
+}
+
+func (t *IdentifiersTokenizer) Split(data []byte, atEOF bool) (advance int, token []byte, err error) {
+	if len(t.parts) > 0 {
+		token, t.parts = t.parts[0], t.parts[1:]
+		return 0, token, nil
+	}
+
+	for start := 0; start < len(data); {
+		if !atEOF && !utf8.FullRune(data[start:]) {
+			return start, nil, nil
+		}
+		r, width := utf8.DecodeRune(data[start:])
+		if !isIdentifierStart(r) && !unicode.IsDigit(r) {
+			start += width
+			continue
+		}
+
+		end, complete := identifierEnd(data, start)
+		if !complete && !atEOF {
+			// Need more data to see the end of the identifier
+			return start, nil, nil
+		}
+		if unicode.IsDigit(r) || !containsLetter(data[start:end]) {
+			// Skip number literals such as 0x1f and blank identifiers
+			start = end
+			continue
+		}
+
+		word := data[start:end]
+		if t.SplitParts {
+			parts := splitIdentifier(word)
+			word, t.parts = parts[0], parts[1:]
+		}
+		return end, word, nil
+	}
+
+	// Need more data
+	return len(data), nil, nil
+}
+
+// identifierEnd() function returns the end of the identifier starting at
+// start, and whether it is followed by data proving it complete.
+func identifierEnd(data []byte, start int) (end int, complete bool) {
+	for i := start; i < len(data); {
+		r, width := utf8.DecodeRune(data[i:])
+		switch {
+		case isIdentifierStart(r) || unicode.IsDigit(r):
+			i += width
+		case r == '.' && i+width < len(data):
+			// A dot continues a qualified name if an identifier follows
+			next, _ := utf8.DecodeRune(data[i+width:])
+			if !isIdentifierStart(next) {
+				return i, true
+			}
+			i += width
+		case r == '.':
+			return i, false
+		default:
+			return i, true
+		}
+	}
+	return len(data), false
+}
+
+// splitIdentifier() function splits the identifier on underscores and dots
+// and at camelCase humps, so HTTPServer_test becomes HTTP, Server and test.
+func splitIdentifier(word []byte) [][]byte {
+	var parts [][]byte
+	for _, field := range bytes.FieldsFunc(word, func(r rune) bool { return r == '_' || r == '.' }) {
+		parts = append(parts, splitCamel(field)...)
+	}
+	if len(parts) == 0 {
+		return [][]byte{word}
+	}
+	return parts
+}
+
+// splitCamel() function splits the word before every upper case letter
+// following a lower case letter or digit, and before the last upper case
+// letter of an acronym followed by a lower case letter.
+func splitCamel(word []byte) [][]byte {
+	var parts [][]byte
+	start := 0
+	prev := utf8.RuneError
+	for i := 0; i < len(word); {
+		r, width := utf8.DecodeRune(word[i:])
+		next, _ := utf8.DecodeRune(word[i+width:])
+		if i > start && unicode.IsUpper(r) &&
+			(unicode.IsLower(prev) || unicode.IsDigit(prev) ||
+				(unicode.IsUpper(prev) && unicode.IsLower(next))) {
+			parts = append(parts, word[start:i])
+			start = i
+		}
+		prev = r
+		i += width
+	}
+	return append(parts, word[start:])
+}
+
+// isIdentifierStart() function reports whether r can start an identifier.
+func isIdentifierStart(r rune) bool {
+	return unicode.IsLetter(r) || r == '_'
+}
+
+// containsLetter() function reports whether the word contains a letter.
+func containsLetter(word []byte) bool {
+	return bytes.IndexFunc(word, unicode.IsLetter) >= 0
+}
@@ -0,0 +1,55 @@
+package scanner
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestIdentifiersTokenizer(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want []string
+	}{
+		{"code", "func (s *Server) ServeHTTP(w http.ResponseWriter) {",
+			[]string{"func", "s", "Server", "ServeHTTP", "w", "http.ResponseWriter"}},
+		{"snake case and digits", "max_len2 = utf8.RuneLen(x)",
+			[]string{"max_len2", "utf8.RuneLen", "x"}},
+		{"number literals", "x := 0x1f + 3.14 + 1e9",
+			[]string{"x"}},
+		{"blank identifiers", "_, err := f()",
+			[]string{"err", "f"}},
+		{"method call on a result", "f().Close()",
+			[]string{"f", "Close"}},
+		{"trailing dot", "end.",
+			[]string{"end"}},
+	}
+	for _, tt := range tests {
+		if got := tokens(t, DefaultConfig(ModeIdentifiers), tt.text); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestIdentifierParts(t *testing.T) {
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{"camelCase", []string{"camel", "Case"}},
+		{"PascalCase", []string{"Pascal", "Case"}},
+		{"snake_case_name", []string{"snake", "case", "name"}},
+		{"HTTPServer_test", []string{"HTTP", "Server", "test"}},
+		{"parseJSON", []string{"parse", "JSON"}},
+		{"utf8Decode", []string{"utf8", "Decode"}},
+		{"base64URL", []string{"base64", "URL"}},
+		{"json.Unmarshal", []string{"json", "Unmarshal"}},
+		{"__init__", []string{"init"}},
+		{"x", []string{"x"}},
+	}
+	for _, tt := range tests {
+		if got := tokens(t, DefaultConfig(ModeIdentifierParts), tt.text); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
@@ -2,88 +2,138 @@ package scanner
 
 import (
 	"bufio"
-	"bytes"
 	"io"
-	"os"
-	"unicode"
-	"unicode/utf8"
 )
 
+// Mode selects how the text is split into tokens.
+type Mode string
+
+const (
+	// ModeLetters splits the text into runs of letters,
+	// dropping everything else.
+	ModeLetters Mode = "letters"
+	// ModeWords splits the text into words of letters, keeping
+	// apostrophes and hyphens inside them, as in don't and well-known.
+	ModeWords Mode = "words"
+	// ModePunctuation splits the text into words and punctuation
+	// marks, returning every punctuation mark as a token of its own.
+	ModePunctuation Mode = "punctuation"
+	// ModeProse splits the text on whitespace, keeping punctuation
+	// attached to words, and emits SentenceBoundary tokens.
+	ModeProse Mode = "prose"
+	// ModeFields splits the text on whitespace only, keeping
+	// every other character, e.g. for shell commands.
+	ModeFields Mode = "fields"
+	// ModeCharacters splits the text into single characters,
+	// dropping whitespace.
+	ModeCharacters Mode = "characters"
+	// ModeIdentifiers splits source code into identifiers and
+	// keywords, keeping qualified names such as http.Handler whole.
+	ModeIdentifiers Mode = "identifiers"
+	// ModeIdentifierParts splits source code into identifiers like
+	// ModeIdentifiers, then splits those on camelCase and snake_case.
+	ModeIdentifierParts Mode = "identifier-parts"
+)
+
+// SentenceBoundary is the token emitted in ModeProse
+// after a sentence ends and at paragraph breaks.
+const SentenceBoundary = "</s>"
+
 // TODO: test config override
 // Config probably should not be visible to the user
 type TextScannerConfig struct {
 	Lowercase bool
+	// Mode defaults to ModeLetters
+	Mode Mode
+	// Tokenizer overrides the tokenizer of Mode
+	Tokenizer Tokenizer
+	// Normalization is applied to every token after splitting
+	Normalization Normalization
 }
 
+// TextScanner splits text into tokens with a Tokenizer
+// and passes them through a normalization Pipeline.
+// Tokens dropped by the pipeline are skipped.
 type TextScanner struct {
 	Config TextScannerConfig
 	*bufio.Scanner
+
+	tokenizer Tokenizer
+	pipeline  Pipeline
+	token     []byte
 }
 
 func New(r io.Reader) (*TextScanner, error) {
-	ts := &TextScanner{
-		Config: TextScannerConfig{Lowercase: true},
-	}
+	return NewWithConfig(r, TextScannerConfig{Lowercase: true})
+}
 
-	ts.Scanner = bufio.NewScanner(r)
-	ts.Scanner.Split(ts.scanWordsNormalized)
-	return ts, nil
+// DefaultConfig() function returns the configuration of the given mode.
+// Case is only folded in ModeLetters, the other modes preserve it.
+func DefaultConfig(mode Mode) TextScannerConfig {
+	return TextScannerConfig{
+		Lowercase: mode == "" || mode == ModeLetters,
+		Mode:      mode,
+	}
 }
 
 // NewWithConfig() function creates a new TextScanner with the given configuration.
-func NewWithConfig(file *os.File, config TextScannerConfig) (*TextScanner, error) {
-	ts := &TextScanner{
-		Config: config,
+func NewWithConfig(r io.Reader, config TextScannerConfig) (*TextScanner, error) {
+	ts := &TextScanner{}
+	if err := ts.SetConfig(config); err != nil {
+		return nil, err
 	}
 
-	ts.Scanner = bufio.NewScanner(file)
-	ts.Scanner.Split(ts.scanWordsNormalized)
+	ts.Scanner = bufio.NewScanner(r)
+	ts.Scanner.Split(func(data []byte, atEOF bool) (int, []byte, error) {
+		return ts.tokenizer.Split(data, atEOF)
+	})
 	return ts, nil
 }
 
-// SetConfig() function sets the configuration for the TextScanner.
-func (ts *TextScanner) SetConfig(config TextScannerConfig) {
-	ts.Config = config
-}
-
-func (ts *TextScanner) scanWordsNormalized(data []byte, atEOF bool) (advance int, token []byte, err error) {
-	start := 0
-
-	// Skip non-letter runes at the beginning
-	for width := 0; start < len(data); start += width {
-		var r rune
-		r, width = utf8.DecodeRune(data[start:])
-		if unicode.IsLetter(r) {
-			break
+// SetConfig() function sets the configuration for the TextScanner,
+// replacing its tokenizer and normalization pipeline.
+func (ts *TextScanner) SetConfig(config TextScannerConfig) error {
+	tokenizer := config.Tokenizer
+	if tokenizer == nil {
+		var err error
+		tokenizer, err = NewTokenizer(config.Mode)
+		if err != nil {
+			return err
 		}
 	}
 
-	// Scan until a non-letter rune
-	for width, i := 0, start; i < len(data); i += width {
-		var r rune
-		r, width = utf8.DecodeRune(data[i:])
-		if !unicode.IsLetter(r) {
-			// Return the word found
-			var word []byte
-			if ts.Config.Lowercase {
-				word = bytes.ToLower(data[start:i])
-			} else {
-				word = data[start:i]
-			}
-
-			return i + width, word, nil
-		}
+	pipeline, err := config.Normalization.Pipeline()
+	if err != nil {
+		return err
+	}
+	if config.Lowercase {
+		pipeline = append(Pipeline{Lowercase}, pipeline...)
 	}
 
-	// If at EOF and still data left, return the last word
-	if atEOF && start < len(data) {
-		word := data[start:]
-		if ts.Config.Lowercase {
-			word = bytes.ToLower(word)
+	ts.Config = config
+	ts.tokenizer = tokenizer
+	ts.pipeline = pipeline
+	return nil
+}
+
+// Scan() function advances to the next token kept by the pipeline.
+func (ts *TextScanner) Scan() bool {
+	for ts.Scanner.Scan() {
+		if token, ok := ts.pipeline.Apply(ts.Scanner.Bytes()); ok {
+			ts.token = token
+			return true
 		}
-		return len(data), word, nil
 	}
+	ts.token = nil
+	return false
+}
+
+// Bytes() function returns the most recent normalized token.
+func (ts *TextScanner) Bytes() []byte {
+	return ts.token
+}
 
-	// Need more data
-	return start, nil, nil
+// Text() function returns the most recent normalized token.
+func (ts *TextScanner) Text() string {
+	return string(ts.token)
 }
@@ -0,0 +1,146 @@
+package scanner
+
+import (
+	"bytes"
+	"fmt"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/cases"
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+// Normalizer normalizes a token, or drops it by returning false.
+type Normalizer func(token []byte) ([]byte, bool)
+
+// Pipeline applies normalizers in order.
+type Pipeline []Normalizer
+
+// Apply() function passes the token through the pipeline. SentenceBoundary
+// tokens are structure rather than text and are passed through untouched.
+func (p Pipeline) Apply(token []byte) ([]byte, bool) {
+	if string(token) == SentenceBoundary {
+		return token, true
+	}
+	for _, normalize := range p {
+		var ok bool
+		if token, ok = normalize(token); !ok {
+			return nil, false
+		}
+	}
+	return token, true
+}
+
+// Form is a Unicode normalization form.
+type Form string
+
+const (
+	// FormNFC composes characters, so é is always a single rune.
+	FormNFC Form = "nfc"
+	// FormNFKC composes characters and replaces compatibility
+	// characters, so ligatures and full-width letters become plain ones.
+	FormNFKC Form = "nfkc"
+)
+
+// Normalization configures the pipeline applied to every token.
+// The steps run in the order of the fields. The zero value
+// leaves the tokens untouched.
+type Normalization struct {
+	Form           Form     `json:"form,omitempty"`
+	FoldDiacritics bool     `json:"fold_diacritics,omitempty"`
+	FoldCase       bool     `json:"fold_case,omitempty"`
+	MinLength      int      `json:"min_length,omitempty"`
+	StopWords      []string `json:"stop_words,omitempty"`
+}
+
+// IsZero() function reports whether the normalization leaves tokens untouched.
+func (n Normalization) IsZero() bool {
+	return n.Form == "" && !n.FoldDiacritics && !n.FoldCase &&
+		n.MinLength == 0 && len(n.StopWords) == 0
+}
+
+// Pipeline() function builds the pipeline of the normalization.
+func (n Normalization) Pipeline() (Pipeline, error) {
+	var p Pipeline
+	switch n.Form {
+	case "":
+	case FormNFC:
+		p = append(p, NFC)
+	case FormNFKC:
+		p = append(p, NFKC)
+	default:
+		return nil, fmt.Errorf("unknown normalization form %q", n.Form)
+	}
+	if n.FoldDiacritics {
+		p = append(p, FoldDiacritics)
+	}
+	if n.FoldCase {
+		p = append(p, FoldCase)
+	}
+	if n.MinLength < 0 {
+		return nil, fmt.Errorf("invalid minimum length %d: must not be negative", n.MinLength)
+	}
+	if n.MinLength > 0 {
+		p = append(p, MinLength(n.MinLength))
+	}
+	if len(n.StopWords) > 0 {
+		p = append(p, StopWords(n.StopWords...))
+	}
+	return p, nil
+}
+
+// NFC() function composes the characters of the token.
+func NFC(token []byte) ([]byte, bool) {
+	return norm.NFC.Bytes(token), true
+}
+
+// NFKC() function composes the characters of the token
+// and replaces compatibility characters.
+func NFKC(token []byte) ([]byte, bool) {
+	return norm.NFKC.Bytes(token), true
+}
+
+// FoldDiacritics() function removes accents and other
+// combining marks, so café becomes cafe.
+func FoldDiacritics(token []byte) ([]byte, bool) {
+	t := transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC)
+	folded, _, err := transform.Bytes(t, token)
+	if err != nil {
+		return token, true
+	}
+	return folded, true
+}
+
+// FoldCase() function folds the case of the token for caseless
+// matching, which unlike Lowercase also maps ß to ss.
+func FoldCase(token []byte) ([]byte, bool) {
+	return cases.Fold().Bytes(token), true
+}
+
+// Lowercase() function lowercases the token.
+func Lowercase(token []byte) ([]byte, bool) {
+	return bytes.ToLower(token), true
+}
+
+// MinLength() function returns a normalizer dropping
+// tokens shorter than n characters.
+func MinLength(n int) Normalizer {
+	return func(token []byte) ([]byte, bool) {
+		return token, utf8.RuneCount(token) >= n
+	}
+}
+
+// StopWords() function returns a normalizer dropping the given words.
+// Words are matched exactly, so they should be normalized like the tokens.
+func StopWords(words ...string) Normalizer {
+	stop := make(map[string]struct{}, len(words))
+	for _, word := range words {
+		stop[word] = struct{}{}
+	}
+	return func(token []byte) ([]byte, bool) {
+		_, ok := stop[string(token)]
+		return token, !ok
+	}
+}
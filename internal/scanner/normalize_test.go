@@ -0,0 +1,82 @@
+package scanner
+
+import (
+	"testing"
+)
+
+func TestNormalizers(t *testing.T) {
+	tests := []struct {
+		name      string
+		normalize Normalizer
+		token     string
+		want      string
+		keep      bool
+	}{
+		{"nfc composes", NFC, "cafe\u0301", "caf\u00e9", true},
+		{"nfkc replaces ligatures", NFKC, "ﬁne", "fine", true},
+		{"nfkc replaces full-width", NFKC, "ＡＢＣ", "ABC", true},
+		{"fold diacritics", FoldDiacritics, "Crème brûlée", "Creme brulee", true},
+		{"fold diacritics of decomposed", FoldDiacritics, "cafe\u0301", "cafe", true},
+		{"fold case", FoldCase, "Straße", "strasse", true},
+		{"lowercase", Lowercase, "Straße", "straße", true},
+		{"long enough", MinLength(3), "añb", "añb", true},
+		{"too short", MinLength(3), "añ", "", false},
+		{"stop word", StopWords("the", "a"), "the", "", false},
+		{"not a stop word", StopWords("the", "a"), "then", "then", true},
+	}
+	for _, tt := range tests {
+		got, keep := tt.normalize([]byte(tt.token))
+		if keep != tt.keep || (keep && string(got) != tt.want) {
+			t.Errorf("%s: got %q, %v, want %q, %v", tt.name, got, keep, tt.want, tt.keep)
+		}
+	}
+}
+
+func TestPipeline(t *testing.T) {
+	tests := []struct {
+		name          string
+		normalization Normalization
+		token         string
+		want          string
+		keep          bool
+	}{
+		{"zero value", Normalization{}, "Café", "Café", true},
+		// Stop words are matched after the other steps
+		{"stop word after folding", Normalization{FoldDiacritics: true, FoldCase: true, StopWords: []string{"cafe"}},
+			"Café", "", false},
+		{"stop words match exactly", Normalization{StopWords: []string{"cafe"}}, "Café", "Café", true},
+		// The length is counted after folding, ß becomes ss
+		{"min length after folding", Normalization{FoldCase: true, MinLength: 3}, "ßa", "ssa", true},
+		{"sentence boundary", Normalization{MinLength: 5, StopWords: []string{SentenceBoundary}},
+			SentenceBoundary, SentenceBoundary, true},
+	}
+	for _, tt := range tests {
+		p, err := tt.normalization.Pipeline()
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		got, keep := p.Apply([]byte(tt.token))
+		if keep != tt.keep || (keep && string(got) != tt.want) {
+			t.Errorf("%s: got %q, %v, want %q, %v", tt.name, got, keep, tt.want, tt.keep)
+		}
+	}
+}
+
+func TestInvalidNormalization(t *testing.T) {
+	for _, n := range []Normalization{{Form: "nfd"}, {MinLength: -1}} {
+		if _, err := n.Pipeline(); err == nil {
+			t.Errorf("%+v: built an invalid pipeline", n)
+		}
+	}
+}
+
+func TestNormalizationIsZero(t *testing.T) {
+	if !(Normalization{}).IsZero() {
+		t.Error("zero value is not zero")
+	}
+	for _, n := range []Normalization{{Form: FormNFC}, {FoldCase: true}, {MinLength: 1}, {StopWords: []string{"a"}}} {
+		if n.IsZero() {
+			t.Errorf("%+v is zero", n)
+		}
+	}
+}
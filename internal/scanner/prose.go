@@ -0,0 +1,101 @@
+package scanner
+
+import (
+	"bytes"
+	"unicode"
+	"unicode/utf8"
+)
+
+// abbreviations end with a period, but rarely end a sentence.
+var abbreviations = map[string]struct{}{
+	"mr.": {}, "mrs.": {}, "ms.": {}, "dr.": {}, "st.": {}, "vs.": {},
+	"e.g.": {}, "i.e.": {}, "cf.": {}, "no.": {}, "jr.": {}, "sr.": {},
+}
+
+// ProseTokenizer splits the text on whitespace, keeping punctuation
+// and case. A SentenceBoundary token follows words ending a sentence
+// and is emitted at paragraph breaks.
+type ProseTokenizer struct {
+	// pendingBoundary is set when a sentence ended,
+	// but its boundary token was not returned yet
+	pendingBoundary bool
+	// inSentence is set after a word, consecutive
+	// boundaries outside sentences are collapsed
+	inSentence bool
+}
+
+func (t *ProseTokenizer) Split(data []byte, atEOF bool) (advance int, token []byte, err error) {
+	if t.pendingBoundary {
+		t.pendingBoundary = false
+		return t.boundary(0)
+	}
+
+	// Skip whitespace at the beginning, looking for paragraph breaks
+	start, newlines := 0, 0
+	for width := 0; start < len(data); start += width {
+		var r rune
+		r, width = utf8.DecodeRune(data[start:])
+		if !unicode.IsSpace(r) {
+			break
+		}
+		if r == '\n' {
+			newlines++
+		}
+	}
+	if newlines > 1 && t.inSentence && (start < len(data) || atEOF) {
+		return t.boundary(start)
+	}
+
+	// Scan until whitespace
+	for width, i := 0, start; i < len(data); i += width {
+		var r rune
+		r, width = utf8.DecodeRune(data[i:])
+		if unicode.IsSpace(r) {
+			return i, t.word(data[start:i]), nil
+		}
+	}
+
+	// If at EOF and still data left, return the last word
+	if atEOF && start < len(data) {
+		return len(data), t.word(data[start:]), nil
+	}
+
+	// Need more data, keeping the newlines to detect paragraph breaks
+	if newlines > 0 {
+		return 0, nil, nil
+	}
+	return start, nil, nil
+}
+
+// word() function notes whether the word ends a sentence.
+func (t *ProseTokenizer) word(word []byte) []byte {
+	t.inSentence = true
+	t.pendingBoundary = EndsSentence(word)
+	return word
+}
+
+// boundary() function returns a SentenceBoundary token.
+func (t *ProseTokenizer) boundary(advance int) (int, []byte, error) {
+	t.inSentence = false
+	return advance, []byte(SentenceBoundary), nil
+}
+
+// EndsSentence() function reports whether the word ends with a sentence
+// terminator, possibly followed by closing quotes or brackets,
+// and is not a common abbreviation such as Mr. or e.g.
+func EndsSentence(word []byte) bool {
+	trimmed := bytes.TrimRightFunc(word, func(r rune) bool {
+		return unicode.Is(unicode.Pf, r) || unicode.Is(unicode.Pe, r) || r == '"' || r == '\''
+	})
+	if len(trimmed) == 0 {
+		return false
+	}
+	if _, ok := abbreviations[string(bytes.ToLower(trimmed))]; ok {
+		return false
+	}
+	switch trimmed[len(trimmed)-1] {
+	case '.', '!', '?':
+		return true
+	}
+	return false
+}
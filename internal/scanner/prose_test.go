@@ -0,0 +1,60 @@
+package scanner
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestProseTokenizer(t *testing.T) {
+	const b = SentenceBoundary
+	tests := []struct {
+		name string
+		text string
+		want []string
+	}{
+		{"sentences", "Hi there. How are you? Fine!",
+			[]string{"Hi", "there.", b, "How", "are", "you?", b, "Fine!", b}},
+		{"abbreviations", "Mr. Smith met Dr. Who, e.g. today.",
+			[]string{"Mr.", "Smith", "met", "Dr.", "Who,", "e.g.", "today.", b}},
+		{"closing quotes and brackets", `He said "stop." Then (he left.) 'Bye.'`,
+			[]string{"He", "said", `"stop."`, b, "Then", "(he", "left.)", b, "'Bye.'", b}},
+		{"curly quotes", "“Go.” Now",
+			[]string{"“Go.”", b, "Now"}},
+		{"paragraph breaks", "A title\n\nSome text\n\n\n\nMore",
+			[]string{"A", "title", b, "Some", "text", b, "More"}},
+		{"single newlines", "one\ntwo",
+			[]string{"one", "two"}},
+		{"no repeated boundaries", "End.\n\nNext",
+			[]string{"End.", b, "Next"}},
+		{"no leading boundary", "\n\nStart",
+			[]string{"Start"}},
+	}
+	for _, tt := range tests {
+		got := tokens(t, DefaultConfig(ModeProse), tt.text)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestEndsSentence(t *testing.T) {
+	tests := []struct {
+		word string
+		want bool
+	}{
+		{"end.", true},
+		{"really?!", true},
+		{`"quoted."`, true},
+		{"(aside.)", true},
+		{"Mr.", false},
+		{"E.G.", false},
+		{"mid,", false},
+		{"", false},
+		{`"`, false},
+	}
+	for _, tt := range tests {
+		if got := EndsSentence([]byte(tt.word)); got != tt.want {
+			t.Errorf("EndsSentence(%q) = %v, want %v", tt.word, got, tt.want)
+		}
+	}
+}
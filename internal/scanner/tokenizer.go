@@ -0,0 +1,194 @@
+package scanner
+
+import (
+	"bufio"
+	"fmt"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Tokenizer splits text into tokens. Split has the semantics
+// of bufio.SplitFunc. Tokenizers may keep state between calls,
+// so every TextScanner needs a tokenizer of its own.
+type Tokenizer interface {
+	Split(data []byte, atEOF bool) (advance int, token []byte, err error)
+}
+
+// tokenizers creates the tokenizer of every mode.
+var tokenizers = map[Mode]func() Tokenizer{
+	ModeLetters:         func() Tokenizer { return LettersTokenizer{} },
+	ModeWords:           func() Tokenizer { return WordsTokenizer{} },
+	ModePunctuation:     func() Tokenizer { return PunctuationTokenizer{} },
+	ModeProse:           func() Tokenizer { return &ProseTokenizer{} },
+	ModeFields:          func() Tokenizer { return FieldsTokenizer{} },
+	ModeCharacters:      func() Tokenizer { return CharactersTokenizer{} },
+	ModeIdentifiers:     func() Tokenizer { return &IdentifiersTokenizer{} },
+	ModeIdentifierParts: func() Tokenizer { return &IdentifiersTokenizer{SplitParts: true} },
+}
+
+// NewTokenizer() function creates the tokenizer of the given mode.
+// The empty mode is ModeLetters.
+func NewTokenizer(mode Mode) (Tokenizer, error) {
+	if mode == "" {
+		mode = ModeLetters
+	}
+
+	newTokenizer, ok := tokenizers[mode]
+	if !ok {
+		return nil, fmt.Errorf("unknown tokenizer mode %q", mode)
+	}
+	return newTokenizer(), nil
+}
+
+// LettersTokenizer returns runs of letters, dropping everything else.
+type LettersTokenizer struct{}
+
+func (LettersTokenizer) Split(data []byte, atEOF bool) (advance int, token []byte, err error) {
+	return scanRuns(data, atEOF, func(data []byte, i int) bool {
+		r, _ := utf8.DecodeRune(data[i:])
+		return unicode.IsLetter(r)
+	})
+}
+
+// WordsTokenizer returns runs of letters joined by single
+// apostrophes or hyphens, so don't and well-known stay whole.
+type WordsTokenizer struct{}
+
+func (WordsTokenizer) Split(data []byte, atEOF bool) (advance int, token []byte, err error) {
+	return scanRuns(data, atEOF, func(data []byte, i int) bool {
+		r, width := utf8.DecodeRune(data[i:])
+		if unicode.IsLetter(r) {
+			return true
+		}
+		if !isJoiner(r) || i == 0 {
+			return false
+		}
+		// A joiner continues the word only between two letters
+		prev, _ := utf8.DecodeLastRune(data[:i])
+		next, _ := utf8.DecodeRune(data[i+width:])
+		return unicode.IsLetter(prev) && unicode.IsLetter(next)
+	})
+}
+
+// PunctuationTokenizer returns words of letters and digits, joined
+// like in WordsTokenizer, and every other visible character
+// as a token of its own.
+type PunctuationTokenizer struct{}
+
+func (PunctuationTokenizer) Split(data []byte, atEOF bool) (advance int, token []byte, err error) {
+	start := skipSpace(data)
+	if start == len(data) {
+		return start, nil, nil
+	}
+	if !atEOF && !utf8.FullRune(data[start:]) {
+		return start, nil, nil
+	}
+
+	r, width := utf8.DecodeRune(data[start:])
+	if !isAlnum(r) {
+		return start + width, data[start : start+width], nil
+	}
+	for i := start + width; i < len(data); {
+		if !atEOF && !utf8.FullRune(data[i:]) {
+			return start, nil, nil
+		}
+		r, width := utf8.DecodeRune(data[i:])
+		if isJoiner(r) && i+width == len(data) && !atEOF {
+			// Need more data to see whether the joiner is inside the word
+			return start, nil, nil
+		}
+		if next, _ := utf8.DecodeRune(data[i+width:]); !isAlnum(r) && !(isJoiner(r) && isAlnum(next)) {
+			return i, data[start:i], nil
+		}
+		i += width
+	}
+	if atEOF {
+		return len(data), data[start:], nil
+	}
+	return start, nil, nil
+}
+
+// FieldsTokenizer returns runs of non-whitespace characters.
+type FieldsTokenizer struct{}
+
+func (FieldsTokenizer) Split(data []byte, atEOF bool) (advance int, token []byte, err error) {
+	return bufio.ScanWords(data, atEOF)
+}
+
+// CharactersTokenizer returns every character but whitespace.
+type CharactersTokenizer struct{}
+
+func (CharactersTokenizer) Split(data []byte, atEOF bool) (advance int, token []byte, err error) {
+	start := skipSpace(data)
+	if start == len(data) || (!atEOF && !utf8.FullRune(data[start:])) {
+		return start, nil, nil
+	}
+	_, width := utf8.DecodeRune(data[start:])
+	return start + width, data[start : start+width], nil
+}
+
+// scanRuns() function returns the next run of runes for which inRun
+// is true, skipping the runes before it. inRun is given the data and
+// the offset of the rune, so it can look at the runes around it.
+func scanRuns(data []byte, atEOF bool, inRun func(data []byte, i int) bool) (advance int, token []byte, err error) {
+	start := 0
+
+	// Skip runes outside runs at the beginning
+	for start < len(data) {
+		if !atEOF && !utf8.FullRune(data[start:]) {
+			return start, nil, nil
+		}
+		if inRun(data, start) {
+			break
+		}
+		_, width := utf8.DecodeRune(data[start:])
+		start += width
+	}
+
+	// Scan until a rune outside the run
+	for i := start; i < len(data); {
+		if !atEOF && !utf8.FullRune(data[i:]) {
+			return start, nil, nil
+		}
+		_, width := utf8.DecodeRune(data[i:])
+		if !inRun(data, i) {
+			if !atEOF && i+width == len(data) {
+				// Whether the rune continues the run may depend on the data following it
+				return start, nil, nil
+			}
+			return i + width, data[start:i], nil
+		}
+		i += width
+	}
+
+	// If at EOF and still data left, return the last run
+	if atEOF && start < len(data) {
+		return len(data), data[start:], nil
+	}
+
+	// Need more data
+	return start, nil, nil
+}
+
+// skipSpace() function returns the offset of the first non-space rune.
+func skipSpace(data []byte) int {
+	start := 0
+	for start < len(data) {
+		r, width := utf8.DecodeRune(data[start:])
+		if !unicode.IsSpace(r) {
+			break
+		}
+		start += width
+	}
+	return start
+}
+
+// isJoiner() function reports whether r may join the parts of a word.
+func isJoiner(r rune) bool {
+	return r == '\'' || r == '’' || r == '-'
+}
+
+// isAlnum() function reports whether r is a letter or a digit.
+func isAlnum(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
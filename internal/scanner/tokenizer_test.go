@@ -0,0 +1,103 @@
+package scanner
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+// tokens() function returns the tokens of the text scanned with the
+// config, read one byte at a time to exercise the need for more data.
+func tokens(t *testing.T, config TextScannerConfig, text string) []string {
+	t.Helper()
+	ts, err := NewWithConfig(iotest.OneByteReader(strings.NewReader(text)), config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for ts.Scan() {
+		got = append(got, ts.Text())
+	}
+	if err := ts.Err(); err != nil {
+		t.Fatal(err)
+	}
+	return got
+}
+
+func TestTokenizers(t *testing.T) {
+	const text = "Don't stop—well-known café, x=1; 'quoted' ls -la|grep"
+	tests := []struct {
+		mode Mode
+		want []string
+	}{
+		{ModeLetters, []string{"don", "t", "stop", "well", "known", "café", "x", "quoted", "ls", "la", "grep"}},
+		{ModeWords, []string{"Don't", "stop", "well-known", "café", "x", "quoted", "ls", "la", "grep"}},
+		{ModePunctuation, []string{"Don't", "stop", "—", "well-known", "café", ",", "x", "=", "1", ";",
+			"'", "quoted", "'", "ls", "-", "la", "|", "grep"}},
+		{ModeFields, []string{"Don't", "stop—well-known", "café,", "x=1;", "'quoted'", "ls", "-la|grep"}},
+	}
+	for _, tt := range tests {
+		if got := tokens(t, DefaultConfig(tt.mode), text); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got %q, want %q", tt.mode, got, tt.want)
+		}
+	}
+}
+
+func TestCharactersTokenizer(t *testing.T) {
+	got := tokens(t, DefaultConfig(ModeCharacters), "añ b\n")
+	if want := []string{"a", "ñ", "b"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestDefaultConfigLowercases(t *testing.T) {
+	tests := []struct {
+		mode Mode
+		want []string
+	}{
+		{"", []string{"the", "cat"}},
+		{ModeLetters, []string{"the", "cat"}},
+		{ModeWords, []string{"The", "Cat"}},
+		{ModeFields, []string{"The", "Cat"}},
+	}
+	for _, tt := range tests {
+		if got := tokens(t, DefaultConfig(tt.mode), "The Cat"); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%q: got %q, want %q", tt.mode, got, tt.want)
+		}
+	}
+}
+
+// lineTokenizer returns whole lines, spaces included.
+type lineTokenizer struct{}
+
+func (lineTokenizer) Split(data []byte, atEOF bool) (int, []byte, error) {
+	if i := bytes.IndexByte(data, '\n'); i >= 0 {
+		return i + 1, data[:i], nil
+	}
+	if atEOF && len(data) > 0 {
+		return len(data), data, nil
+	}
+	return 0, nil, nil
+}
+
+func TestCustomTokenizer(t *testing.T) {
+	config := TextScannerConfig{
+		Tokenizer:     lineTokenizer{},
+		Normalization: Normalization{FoldCase: true},
+	}
+	got := tokens(t, config, "New York\nLos Angeles")
+	if want := []string{"new york", "los angeles"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestNewTokenizer(t *testing.T) {
+	if _, err := NewTokenizer(""); err != nil {
+		t.Errorf("empty mode: %v", err)
+	}
+	if _, err := NewTokenizer("bogus"); err == nil {
+		t.Error("created a tokenizer of an unknown mode")
+	}
+}
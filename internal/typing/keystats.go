@@ -0,0 +1,183 @@
+package typing
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+	"unicode"
+)
+
+// KeyStat accumulates how often a character or bigram
+// was typed, how many times it was wrong and how long it took.
+type KeyStat struct {
+	Count   int           `json:"count"`
+	Errors  int           `json:"errors"`
+	Latency time.Duration `json:"latency"`
+	Timed   int           `json:"timed"`
+}
+
+// ErrorRate() function returns the smoothed share of wrong keystrokes,
+// so a single mistake on a rarely typed key is not taken as 100%.
+func (ks KeyStat) ErrorRate() float64 {
+	return float64(ks.Errors+1) / float64(ks.Count+2)
+}
+
+// MeanLatency() function returns the average time it took to type the key.
+func (ks KeyStat) MeanLatency() time.Duration {
+	if ks.Timed == 0 {
+		return 0
+	}
+	return ks.Latency / time.Duration(ks.Timed)
+}
+
+// KeyStats holds per character and per bigram statistics
+// accumulated over many typing sessions.
+type KeyStats struct {
+	Chars   map[string]*KeyStat `json:"chars"`
+	Bigrams map[string]*KeyStat `json:"bigrams"`
+}
+
+// NewKeyStats() function creates empty key statistics.
+func NewKeyStats() *KeyStats {
+	return &KeyStats{
+		Chars:   make(map[string]*KeyStat),
+		Bigrams: make(map[string]*KeyStat),
+	}
+}
+
+// maxLatency caps the time counted for a single keystroke,
+// so pauses between words do not distort the averages.
+const maxLatency = 2 * time.Second
+
+// AddSession() function accumulates the keystrokes of a finished session.
+// Only letters and symbols are counted, whitespace is skipped.
+func (s *KeyStats) AddSession(ts TypingSession) {
+	expected := []rune(ts.ExpectedText)
+	var prev *Keystroke
+
+	for i := range ts.Keystrokes {
+		k := &ts.Keystrokes[i]
+		index := k.Position - 1
+		typed := !k.IsBackspace && len(k.TypedChar) > 0 && index >= 0 && index < len(expected)
+		if !typed {
+			prev = nil
+			continue
+		}
+
+		char := expected[index]
+		if unicode.IsSpace(char) {
+			prev = k
+			continue
+		}
+
+		var latency time.Duration
+		if prev != nil {
+			latency = k.Timestamp.Sub(prev.Timestamp)
+		}
+
+		s.add(s.Chars, string(char), k.IsCorrect, latency)
+		if prev != nil && prev.IsCorrect && prev.Position == k.Position-1 && index > 0 && !unicode.IsSpace(expected[index-1]) {
+			s.add(s.Bigrams, string(expected[index-1:index+1]), k.IsCorrect, latency)
+		}
+		prev = k
+	}
+}
+
+func (s *KeyStats) add(m map[string]*KeyStat, key string, correct bool, latency time.Duration) {
+	stat, ok := m[key]
+	if !ok {
+		stat = &KeyStat{}
+		m[key] = stat
+	}
+
+	stat.Count++
+	if !correct {
+		stat.Errors++
+	}
+	if latency > 0 && latency <= maxLatency {
+		stat.Latency += latency
+		stat.Timed++
+	}
+}
+
+// Total() function returns the statistics summed over all characters.
+func (s *KeyStats) Total() KeyStat {
+	var total KeyStat
+	for _, stat := range s.Chars {
+		total.Count += stat.Count
+		total.Errors += stat.Errors
+		total.Latency += stat.Latency
+		total.Timed += stat.Timed
+	}
+	return total
+}
+
+// MeanLatency() function returns the average latency over all characters.
+func (s *KeyStats) MeanLatency() time.Duration {
+	return s.Total().MeanLatency()
+}
+
+// EncodeJSON() function encodes the statistics to JSON.
+func (s *KeyStats) EncodeJSON(w io.Writer) error {
+	return json.NewEncoder(w).Encode(s)
+}
+
+// DecodeJSON() function decodes the statistics from JSON.
+func (s *KeyStats) DecodeJSON(r io.Reader) error {
+	if err := json.NewDecoder(r).Decode(s); err != nil {
+		return err
+	}
+	if s.Chars == nil {
+		s.Chars = make(map[string]*KeyStat)
+	}
+	if s.Bigrams == nil {
+		s.Bigrams = make(map[string]*KeyStat)
+	}
+	return nil
+}
+
+// DefaultKeyStatsPath() function returns the file the statistics
+// are kept in between runs, inside the user configuration directory.
+func DefaultKeyStatsPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "keybon", "stats.json"), nil
+}
+
+// LoadKeyStats() function reads the statistics from a file.
+// A missing file gives empty statistics.
+func LoadKeyStats(path string) (*KeyStats, error) {
+	s := NewKeyStats()
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	if err := s.DecodeJSON(file); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// SaveKeyStats() function writes the statistics to a file,
+// creating its directory if needed.
+func SaveKeyStats(path string, s *KeyStats) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return s.EncodeJSON(file)
+}
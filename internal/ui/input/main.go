@@ -16,6 +16,9 @@ import (
 const (
 	defaultWidth  = 100
 	defaultHeight = 5
+	tabWidth      = 4
+	// newlineSymbol is displayed in place of expected newlines
+	newlineSymbol = '↵'
 )
 
 var (
@@ -39,6 +42,10 @@ type Model struct {
 	pos   int
 	focus bool
 
+	// SkipIndent makes the indentation after a typed newline
+	// filled in automatically, so only the code itself is typed.
+	SkipIndent bool
+
 	CorrectStyle              lipgloss.Style
 	WrongStyle                lipgloss.Style
 	PendingStyle              lipgloss.Style
@@ -174,11 +181,13 @@ func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
 	var cmd tea.Cmd
 
 	oldPos := m.pos
+	var keystrokeCmd tea.Cmd
 
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
 		isCorrect := false
 		isBack := false
+		typed := msg.Runes
 
 		switch {
 		case !m.Focused():
@@ -197,26 +206,31 @@ func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
 			// Typing: compare against target *before* inserting
 		case msg.Type == tea.KeyRunes || msg.Type == tea.KeySpace:
 			for _, r := range msg.Runes {
-				if m.pos < len(m.expectedText) {
-					expected := m.expectedText[m.pos]
-					m.insertRune(r)
-					if r == expected {
-						isCorrect = true
-					}
+				if m.typeRune(r) {
+					isCorrect = true
 				}
 			}
+		case msg.Type == tea.KeyEnter:
+			typed = []rune{'\n'}
+			isCorrect = m.typeRune('\n')
+		case msg.Type == tea.KeyTab:
+			typed, isCorrect = m.typeIndent()
 		}
 
-		cmd = func() tea.Msg {
+		// Capture the position now, the model is reset once the input is complete
+		pos := m.pos
+		if m.SkipIndent && msg.Type == tea.KeyEnter {
+			m.skipIndent()
+		}
+		keystrokeCmd = func() tea.Msg {
 			return KeystrokeProcessedMsg{
-				TypedChar:   msg.Runes,
+				TypedChar:   typed,
 				IsCorrect:   isCorrect,
 				IsBackspace: isBack,
-				Position:    m.pos,
+				Position:    pos,
 				Timestamp:   time.Now(),
 			}
 		}
-		cmds = append(cmds, cmd)
 	}
 
 	m.Cursor, cmd = m.Cursor.Update(msg)
@@ -235,12 +249,46 @@ func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
 			}
 		}
 		m.Reset()
-		cmds = append(cmds, cmd)
+		// The last keystroke must be recorded before the input is complete
+		keystrokeCmd = tea.Sequence(keystrokeCmd, cmd)
 	}
+	cmds = append(cmds, keystrokeCmd)
 
 	return m, tea.Batch(cmds...)
 }
 
+// typeRune() types a rune and reports whether it was the expected one.
+func (m *Model) typeRune(r rune) bool {
+	if m.pos >= len(m.expectedText) {
+		return false
+	}
+	expected := m.expectedText[m.pos]
+	m.insertRune(r)
+	return r == expected
+}
+
+// typeIndent() types the expected spaces, up to the tab width,
+// or a single wrong space if none is expected.
+// It returns the typed spaces and whether they were expected.
+func (m *Model) typeIndent() ([]rune, bool) {
+	var typed []rune
+	for len(typed) < tabWidth && m.pos < len(m.expectedText) && m.expectedText[m.pos] == ' ' {
+		m.insertRune(' ')
+		typed = append(typed, ' ')
+	}
+	if len(typed) == 0 {
+		return []rune{' '}, m.typeRune(' ')
+	}
+	return typed, true
+}
+
+// skipIndent() fills in the expected indentation at the cursor.
+func (m *Model) skipIndent() {
+	for m.pos == len(m.typedText) && m.pos < len(m.expectedText) && m.expectedText[m.pos] == ' ' {
+		m.insertRune(' ')
+	}
+}
+
 // insertRune() inserts a rune at the cursor position.
 func (m *Model) insertRune(r rune) {
 	if r == 0 {
@@ -286,12 +334,12 @@ func (m *Model) deleteWordBackward() {
 
 // View() returns the view of the model.
 func (m Model) View() string {
-	// TODO: add viewport
 	var (
 		lines        []string
 		currentLine  []string
 		currentWidth int
 		cursorPlaced bool
+		cursorLine   int
 		wordBuffer   []rune
 		startPos     int
 	)
@@ -305,6 +353,7 @@ func (m Model) View() string {
 		chunkWidth := 0
 		wordTyped := true
 		wordCorrect := true
+		cursorInChunk := false
 
 		// Check if the word is fully typed and if it's correct
 		for i, r := range wordBuffer {
@@ -350,15 +399,21 @@ func (m Model) View() string {
 				style = m.CorrectStyle
 			}
 
-			styled := style.Render(string(r))
+			display := r
+			if r == '\n' {
+				display = newlineSymbol
+			}
+
+			styled := style.Render(string(display))
 			if pos == m.pos && !cursorPlaced {
-				m.Cursor.SetChar(string(r))
+				m.Cursor.SetChar(string(display))
 				styled = m.Cursor.View()
 				cursorPlaced = true
+				cursorInChunk = true
 			}
 
 			chunk.WriteString(styled)
-			chunkWidth += runewidth.RuneWidth(r)
+			chunkWidth += runewidth.RuneWidth(display)
 		}
 
 		if currentWidth+chunkWidth > m.width {
@@ -366,6 +421,9 @@ func (m Model) View() string {
 			currentLine = nil
 			currentWidth = 0
 		}
+		if cursorInChunk {
+			cursorLine = len(lines)
+		}
 
 		currentLine = append(currentLine, chunk.String())
 		currentWidth += chunkWidth
@@ -380,19 +438,28 @@ func (m Model) View() string {
 		if unicode.IsSpace(r) || i == len(m.expectedText)-1 {
 			flush()
 		}
+
+		// newlines always break the line
+		if r == '\n' {
+			lines = append(lines, strings.Join(currentLine, ""))
+			currentLine = nil
+			currentWidth = 0
+		}
 	}
 
 	if len(currentLine) > 0 {
 		lines = append(lines, strings.Join(currentLine, ""))
 	}
 
-	// Ensure exactly m.height lines
+	// Ensure exactly m.height lines, scrolling
+	// to keep the cursor on the second line
 	if len(lines) < m.height {
 		for len(lines) < m.height {
 			lines = append(lines, "")
 		}
 	} else if len(lines) > m.height {
-		lines = lines[:m.height]
+		start := clamp(cursorLine-1, 0, len(lines)-m.height)
+		lines = lines[start : start+m.height]
 	}
 
 	// ANSI-aware truncate and pad to exactly m.width
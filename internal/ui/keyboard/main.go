@@ -12,9 +12,16 @@ type Model struct {
 	pressedKey string
 	keyLayout  [][]string
 	nextKey    string
-
-	KeyStyle     lipgloss.Style
-	NextKeyStyle lipgloss.Style
+	// shifted is true when the next key is typed with shift held
+	shifted bool
+	// shiftedKeys maps shifted characters to the keys that type them
+	shiftedKeys map[string]string
+	// allowedKeys is nil when all keys are allowed
+	allowedKeys map[string]struct{}
+
+	KeyStyle       lipgloss.Style
+	NextKeyStyle   lipgloss.Style
+	LockedKeyStyle lipgloss.Style
 }
 
 type Language int
@@ -36,23 +43,69 @@ var (
 				Foreground(lipgloss.Color("82")).
 				BorderForeground(lipgloss.Color("82")).
 				Bold(true)
+
+	defaultLockedKeyStyle = defaultKeyStyle.
+				Foreground(lipgloss.Color("240")).
+				BorderForeground(lipgloss.Color("238"))
 )
 
+// ShiftKey is the name of the shift key in the layout.
+const ShiftKey = "shift"
+
+// NextKey() function highlights the key typing the given character,
+// together with the shift key for uppercase letters and shifted symbols.
 func (m *Model) NextKey(k string) {
+	m.shifted = false
+	if base, ok := m.shiftedKeys[k]; ok {
+		k = base
+		m.shifted = true
+	} else if lower := strings.ToLower(k); lower != k {
+		k = lower
+		m.shifted = true
+	}
 	m.nextKey = k
 }
 
+// SetAllowedKeys() function marks all other keys as locked.
+// Passing nil unlocks all keys.
+func (m *Model) SetAllowedKeys(keys []string) {
+	if keys == nil {
+		m.allowedKeys = nil
+		return
+	}
+	m.allowedKeys = make(map[string]struct{}, len(keys))
+	for _, k := range keys {
+		m.allowedKeys[k] = struct{}{}
+	}
+}
+
+// isLocked() function reports whether the key is not allowed.
+func (m Model) isLocked(k string) bool {
+	if m.allowedKeys == nil || k == ShiftKey {
+		return false
+	}
+	_, ok := m.allowedKeys[k]
+	return !ok
+}
+
 func New(lang Language) (Model, error) {
 	switch lang {
 	case En:
 		return Model{
 			keyLayout: [][]string{
+				{"1", "2", "3", "4", "5", "6", "7", "8", "9", "0"},
 				{"q", "w", "e", "r", "t", "y", "u", "i", "o", "p"},
-				{"a", "s", "d", "f", "g", "h", "j", "k", "l", ";"},
-				{"z", "x", "c", "v", "b", "n", "m", ",", ".", "/"},
+				{"a", "s", "d", "f", "g", "h", "j", "k", "l", ";", "'"},
+				{ShiftKey, "z", "x", "c", "v", "b", "n", "m", ",", ".", "/"},
+			},
+			shiftedKeys: map[string]string{
+				"!": "1", "@": "2", "#": "3", "$": "4", "%": "5",
+				"^": "6", "&": "7", "*": "8", "(": "9", ")": "0",
+				":": ";", "\"": "'", "<": ",", ">": ".", "?": "/",
 			},
-			KeyStyle:     defaultKeyStyle,
-			NextKeyStyle: defaultNextKeyStyle,
+			KeyStyle:       defaultKeyStyle,
+			NextKeyStyle:   defaultNextKeyStyle,
+			LockedKeyStyle: defaultLockedKeyStyle,
 		}, nil
 	default:
 		return Model{}, fmt.Errorf("unsupported language: %v", lang)
@@ -84,9 +137,12 @@ func (m Model) View() string {
 		var keys []string
 		for _, k := range row {
 			var keyRender string
-			if k == m.nextKey {
+			switch {
+			case k == m.nextKey, k == ShiftKey && m.shifted:
 				keyRender = defaultNextKeyStyle.Render(strings.ToUpper(k))
-			} else {
+			case m.isLocked(k):
+				keyRender = defaultLockedKeyStyle.Render(strings.ToUpper(k))
+			default:
 				keyRender = defaultKeyStyle.Render(strings.ToUpper(k))
 			}
 			keys = append(keys, keyRender)
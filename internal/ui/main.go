@@ -1,6 +1,7 @@
 package ui
 
 import (
+	"errors"
 	"strings"
 
 	"github.com/abilun/keybon/internal/generator"
@@ -17,6 +18,7 @@ type State int
 const (
 	mainView State = iota
 	resultsView
+	finishedView
 )
 
 type model struct {
@@ -25,15 +27,37 @@ type model struct {
 	typingSession typing.TypingSession
 	generator     generator.Generator
 	wordsCount    int
+	attribution   string
 
 	input         input.Model
 	resultsScreen results.Model
 	keyboard      keyboard.Model
 
+	onSessionComplete func(typing.TypingSession)
+
 	height int
 	width  int
 }
 
+// Option configures the main screen.
+type Option func(*model)
+
+// WithSkipIndent() option fills in the indentation
+// after typed newlines automatically.
+func WithSkipIndent() Option {
+	return func(m *model) {
+		m.input.SkipIndent = true
+	}
+}
+
+// WithSessionHook() option sets a function
+// that is called with every finished typing session.
+func WithSessionHook(hook func(typing.TypingSession)) Option {
+	return func(m *model) {
+		m.onSessionComplete = hook
+	}
+}
+
 func (m model) Init() tea.Cmd {
 	return tea.Batch(
 		m.input.Init(),
@@ -53,16 +77,18 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	switch msg := msg.(type) {
 	case refreshWordsMsg:
-		words := make([]string, 0, m.wordsCount)
-		for i := 0; i < m.wordsCount; i++ {
-			word, err := m.generator.Next()
-			if err != nil {
-				return m, tea.Quit
-			}
-			words = append(words, word)
+		text, err := m.nextText()
+		if errors.Is(err, generator.ErrExhausted) {
+			m.state = finishedView
+			return m, nil
+		}
+		if err != nil {
+			return m, tea.Quit
 		}
-		text := strings.Join(words, " ")
 		m.input.SetExpectedText(text)
+		if r, ok := m.generator.(generator.KeyRestricter); ok {
+			m.keyboard.SetAllowedKeys(r.AllowedKeys())
+		}
 
 	case input.InputCompleteMsg:
 		m.typingSession.TypedText = msg.TypedText
@@ -70,6 +96,9 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		// TODO: worth setting somewhere else to decouple session from input
 		m.typingSession.ExpectedText = m.input.GetExpectedText()
 		stats := m.typingSession.Stats()
+		if m.onSessionComplete != nil {
+			m.onSessionComplete(m.typingSession)
+		}
 
 		m.resultsScreen = results.Model{
 			KeysPressedTotal:   stats.KeysPressedTotal,
@@ -77,6 +106,7 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			Accuracy:           stats.Accuracy,
 			Duration:           stats.Duration,
 			WPM:                stats.WPM,
+			Attribution:        m.attribution,
 		}
 
 	case results.BackMsg:
@@ -105,6 +135,13 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	}
 
 	switch m.state {
+	case finishedView:
+		if msg, ok := msg.(tea.KeyMsg); ok {
+			switch msg.Type {
+			case tea.KeyCtrlC, tea.KeyEsc, tea.KeyEnter:
+				return m, tea.Quit
+			}
+		}
 	case resultsView:
 		m.resultsScreen, cmd = m.resultsScreen.Update(msg)
 		cmds = append(cmds, cmd)
@@ -129,11 +166,42 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, tea.Batch(cmds...)
 }
 
+// nextText() function returns the text of the next session:
+// a whole passage if the generator produces them,
+// otherwise the configured number of words.
+func (m *model) nextText() (string, error) {
+	if p, ok := m.generator.(generator.Passager); ok {
+		passage, err := p.NextPassage()
+		if err != nil {
+			return "", err
+		}
+		m.attribution = passage.Attribution
+		return passage.Text, nil
+	}
+
+	words := make([]string, 0, m.wordsCount)
+	for i := 0; i < m.wordsCount; i++ {
+		word, err := m.generator.Next()
+		if err != nil {
+			return "", err
+		}
+		words = append(words, word)
+	}
+	return strings.Join(words, " "), nil
+}
+
 func (m model) View() string {
 	b := strings.Builder{}
 	var view string
 
 	switch m.state {
+	case finishedView:
+		view = borderStyle.Render(lipgloss.JoinVertical(lipgloss.Center,
+			"Finished!",
+			"",
+			"You have typed the whole text.",
+			"Press Enter or Esc to quit.",
+		))
 	case resultsView:
 		view = borderStyle.Render(m.resultsScreen.View())
 	case mainView:
@@ -161,10 +229,13 @@ func New() model {
 	}
 }
 
-func StartMainScreen(gen generator.Generator, wordsCount int) error {
+func StartMainScreen(gen generator.Generator, wordsCount int, opts ...Option) error {
 	ms := New()
 	ms.generator = gen
 	ms.wordsCount = wordsCount
+	for _, opt := range opts {
+		opt(&ms)
+	}
 
 	p := tea.NewProgram(
 		ms,
@@ -8,12 +8,18 @@ import (
 	"github.com/charmbracelet/lipgloss"
 )
 
+var attributionStyle = lipgloss.NewStyle().
+	Foreground(lipgloss.Color("8")).
+	Italic(true)
+
 type Model struct {
 	KeysPressedTotal   int
 	KeysPressedCorrect int
 	Accuracy           float32
 	Duration           time.Duration
 	WPM                float64
+	// Attribution of the typed passage, if any
+	Attribution string
 }
 
 func (m Model) Init() tea.Cmd {
@@ -49,6 +55,9 @@ func (m Model) View() string {
 		"",
 		fmt.Sprintf("WPM: %.2f", m.WPM),
 	}
+	if m.Attribution != "" {
+		lines = append(lines, "", attributionStyle.Render("— "+m.Attribution))
+	}
 
 	return lipgloss.JoinVertical(lipgloss.Center, lines...)
 }